@@ -0,0 +1,30 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"slices"
+	"unicode/utf8"
+)
+
+// AppendEncodeString appends the StdEncoding encoding of src to buf, see
+// Encoding.AppendEncodeString.
+func AppendEncodeString(buf []byte, src []byte) []byte {
+	return StdEncoding.AppendEncodeString(buf, src)
+}
+
+// AppendEncodeString appends the UTF-8 encoding of src to buf and returns the
+// extended buffer, like EncodeToString but without allocating a string for
+// every encoding, e.g. when assembling many encoded fragments into one output.
+// buf grows at most once per call, not at all if it has room for
+// EncodedByteLength(len(src)) more bytes.
+func (enc *Encoding) AppendEncodeString(buf []byte, src []byte) []byte {
+	if table := enc.tableFor(); table != nil && len(src) > 0 {
+		return appendEncode(enc, table, buf, src)
+	}
+	buf = slices.Grow(buf, enc.EncodedByteLength(len(src)))
+	enc.encodeRunes(src, func(r rune) { buf = utf8.AppendRune(buf, r) })
+	return buf
+}
@@ -0,0 +1,64 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestAppendEncodeString(t *testing.T) {
+	var expected strings.Builder
+	var buf []byte
+	for n, encoded := range encodeExpectedStrings {
+		buf = AppendEncodeString(buf, srcData[:n])
+		expected.WriteString(encoded)
+		if string(buf) != expected.String() {
+			t.Error(fmt.Sprintf("[%d] Expected %s, got %s", n, expected.String(), buf))
+		}
+	}
+	for _, enc := range []*Encoding{SafeEncoding, BMPEncoding, StdEncoding.WithExclusions(testExclusions...)} {
+		data := excludedData()
+		if encoded := enc.AppendEncodeString([]byte("x"), data); string(encoded) != "x"+enc.EncodeToString(data) {
+			t.Error(fmt.Sprintf("[%s] Unexpected encoding %s", enc, encoded))
+		}
+	}
+	// With enough room, nothing is allocated.
+	buf = make([]byte, 0, EncodedByteLength(len(srcData)))
+	if allocs := testing.AllocsPerRun(100, func() { AppendEncodeString(buf, srcData) }); allocs != 0 {
+		t.Error(fmt.Sprintf("Expected no allocations, got %v", allocs))
+	}
+}
+
+func BenchmarkAppendEncodeString(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	fragments := make([][]byte, 1000)
+	for i := range fragments {
+		fragments[i] = make([]byte, rng.Intn(64))
+		rng.Read(fragments[i])
+	}
+	b.Run("append", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf []byte
+		for i := 0; i < b.N; i += 1 {
+			buf = buf[:0]
+			for _, fragment := range fragments {
+				buf = AppendEncodeString(buf, fragment)
+			}
+		}
+	})
+	b.Run("builder", func(b *testing.B) {
+		b.ReportAllocs()
+		var builder strings.Builder
+		for i := 0; i < b.N; i += 1 {
+			builder.Reset()
+			for _, fragment := range fragments {
+				builder.WriteString(EncodeToString(fragment))
+			}
+		}
+	})
+}
@@ -23,17 +23,23 @@ rights to this work are waived. See the LICENSE file for more information. */
 // Still, this encoding slightly outperforms base64 and even base122 in the
 // space available in a single tweet:
 //
-//          space ratio   char ratio   bytes per tweet
-//  base64     0.75           6           210
-//  base122    0.875          7           245
-//  base32k    0.625         15           256
-//           ( more is better for all columns )
+//	        space ratio   char ratio   bytes per tweet
+//	base64     0.75           6           210
+//	base122    0.875          7           245
+//	base32k    0.625         15           260
+//	         ( more is better for all columns )
 //
 // base32k outperforming base122 on twitter results from the fact that twitter
 // counts a CJK or Hangul glyph as two characters, whereas in utf8 it's
 // actually 3 characters. This gives us, in effect, an encoding ratio of 15/16
 // over base122's 7/8, a slight advantage.
 //
+// Platforms that count grapheme clusters instead of code points must count
+// each glyph as one character too, i.e. adjacent glyphs must never combine.
+// None of the lanes contain combining marks, joiners, emoji or conjoining
+// jamo, and Hangul glyphs are always precomposed syllables, which don't join
+// each other, so every glyph is a grapheme cluster of its own.
+//
 // So, given good-enough font coverage of the basic multilingual unicode
 // plane, this can be used to transmit data in situations where characters are
 // limited, rather than disk space.
@@ -47,6 +53,9 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
 )
 
 // Code-Point-ranges ("lanes")
@@ -85,7 +94,13 @@ import (
 const BITS_PER_RUNE = 15
 const BYTES_PER_RUNE = 15
 const BYTE_LEN = 8
+
+// PAD_START_SYMBOL is the base of the padding symbols: the padding symbol is
+// PAD_START_SYMBOL plus the number of data bits in the final glyph, from 1 to
+// 14 for StdEncoding. PAD_START_SYMBOL itself would pad a glyph without any
+// data bits, which the encoder never writes, so decoding rejects it.
 const PAD_START_SYMBOL = rune('a') // 0x61
+const GLYPHS_PER_TWEET = 140       // 280 characters, CJK glyphs count as two
 
 var toLane = [...]uint16{ // {3 MSBs -> prefix}
 	/*0b000:*/ 0x8000, // 1.000 [0]
@@ -117,146 +132,1133 @@ var fromLane = [...]byte{
 	/*0xf:*/ 0xff, // invalid
 }
 
-// Encode encodes a given byte array of data into a base32k byte array.
-func Encode(src []byte) (dest []byte) { return encode(src) }
+// CorruptInputError is returned by the decoding functions when the input is
+// not valid base32k. Position is the index of the offending rune (not byte) in
+// the input.
+type CorruptInputError struct {
+	Position int
+	Rune     rune
+	Reason   string
+}
+
+func (e CorruptInputError) Error() string {
+	return fmt.Sprintf("%s at position %d: %s", e.Reason, e.Position, string(e.Rune))
+}
+
+// ErrUnexpectedEnd is returned when the input ends without a padding symbol,
+// but its glyphs don't add up to a whole number of bytes, or in the middle of
+// the UTF-8 sequence of a glyph. This happens when an encoding is truncated or
+// its padding symbol was lost, so unlike a CorruptInputError, more input may
+// still make it valid. It matches io.ErrUnexpectedEOF with errors.Is.
+var ErrUnexpectedEnd error = unexpectedEnd{}
+
+type unexpectedEnd struct{}
+
+func (unexpectedEnd) Error() string { return "Unexpected end of input: padding character missing" }
+func (unexpectedEnd) Unwrap() error { return io.ErrUnexpectedEOF }
+
+// ErrEmptyInput is returned by encodings created with RequireNonEmpty when
+// asked to encode or decode empty input.
+var ErrEmptyInput = errors.New("Empty input")
+
+// ErrInputTooLarge is returned by encodings created with WithMaxInputBytes
+// when asked to encode or decode input above the limit.
+var ErrInputTooLarge = errors.New("Input exceeds maximum size")
+
+// ErrShortBuffer is returned by DecodeInto if the destination buffer is too
+// small to hold the decoded data.
+var ErrShortBuffer = errors.New("Destination buffer too short")
+
+// An Encoding is a base32k alphabet. It describes the lanes of 4096 code
+// points each that the glyphs are placed into, and how many bits of data each
+// glyph carries.
+type Encoding struct {
+	bitsPerRune uint
+	toLane      []uint16 // {value >> 12 -> lane prefix}
+	fromLane    []byte   // {rune >> 12 -> value >> 12, 0xfe: padding, 0xff: invalid}
+	bmp         bool     // no lanes, glyphs are offset by bmpOffset instead
+	exclusions  []RuneRange
+	padStart    rune
+	recoverNFD  bool
+	nonEmpty    bool
+	strictPad   bool
+	trimSpace   bool
+	marker      bool
+	lengthGlyph bool
+	table       *glyphTable
+	maxInput    int
+}
+
+// NoPadding can be passed to Encoding.WithPadding to create an encoding
+// without a padding symbol.
+const NoPadding rune = -1
+
+// StdEncoding is the standard base32k encoding with 15 bits per glyph, as
+// described in the package documentation. The package-level functions use
+// this encoding.
+var StdEncoding = &Encoding{
+	bitsPerRune: BITS_PER_RUNE, toLane: toLane[:8], fromLane: fromLane[:], padStart: PAD_START_SYMBOL,
+}
+
+// SafeEncoding is an alternative encoding which only uses glyphs from the part
+// of the CJK Unified Ideographs block (U+5000 - U+8FFF) that has been assigned
+// since Unicode 1.1 and is covered by any CJK font. This avoids the CJK
+// Extension A, late CJK and Hangul code points used by StdEncoding, some of
+// which render as tofu in common fonts.
+//
+// With only 4 lanes available, the encoding ratio drops to 14 bits per glyph,
+// i.e. 14/24 (0.583) instead of 15/24 (0.625). The padding symbol works the
+// same as in StdEncoding.
+var SafeEncoding = &Encoding{
+	bitsPerRune: 14, toLane: safeToLane[:], fromLane: safeFromLane[:], padStart: PAD_START_SYMBOL,
+}
+
+// BMPEncoding is an experimental encoding with 16 bits per glyph, i.e. a ratio
+// of 16/24 (0.667), which uses the whole basic multilingual plane instead of
+// the lanes: a glyph is its value offset by 256 to skip the ASCII and Latin-1
+// characters, which are left for the padding symbols, and past the surrogate
+// code points. This pushes the highest 2304 values into the supplementary
+// multilingual plane (U+10000 - U+108FF).
+//
+// The price is that the encoding contains control characters, combining
+// marks, unassigned and private use code points and whatever else the BMP
+// holds, most of which either won't render or won't survive transport: only
+// use it for media that pass any code point through unchanged. Its glyphs are
+// between 2 and 4 bytes of UTF-8, and it is not stable under any Unicode
+// normalization.
+var BMPEncoding = &Encoding{bitsPerRune: 16, bmp: true, padStart: PAD_START_SYMBOL}
+
+// bmpOffset is the first glyph of BMPEncoding. The surrogate code points
+// U+D800 - U+DFFF are skipped, because they can't be encoded in UTF-8.
+const bmpOffset = 0x100
+const surrogateStart, surrogateEnd = 0xd800, 0xe000
+
+// byteOrderMark is the BOM that editors may put at the start of a UTF-8 file.
+// It is skipped by the decoders, except for BMPEncoding, where it is a glyph.
+const byteOrderMark = '\ufeff'
+
+// isByteOrderMark reports whether r is a byte order mark rather than a glyph.
+func (enc *Encoding) isByteOrderMark(r rune) bool { return r == byteOrderMark && !enc.bmp }
+
+var safeToLane = [...]uint16{ // {2 MSBs -> prefix}
+	/*0b00:*/ 0x5000,
+	/*0b01:*/ 0x6000,
+	/*0b10:*/ 0x7000,
+	/*0b11:*/ 0x8000,
+}
+var safeFromLane = [...]byte{
+	/*0x0:*/ 0xfe, // padding
+	/*0x1:*/ 0xff, // invalid
+	/*0x2:*/ 0xff, // invalid
+	/*0x3:*/ 0xff, // invalid
+	/*0x4:*/ 0xff, // invalid
+	/*0x5:*/ 0, //    .00
+	/*0x6:*/ 1, //    .01
+	/*0x7:*/ 2, //    .10
+	/*0x8:*/ 3, //    .11
+	/*0x9:*/ 0xff, // invalid
+	/*0xa:*/ 0xff, // invalid
+	/*0xb:*/ 0xff, // invalid
+	/*0xc:*/ 0xff, // invalid
+	/*0xd:*/ 0xff, // invalid
+	/*0xe:*/ 0xff, // invalid
+	/*0xf:*/ 0xff, // invalid
+}
+
+// Encode encodes a given byte array of data into a base32k byte array. Empty
+// input encodes to an empty (nil) result, which decodes back to empty data.
+// See Encoding.RequireNonEmpty for protocols that must reject empty input.
+func Encode(src []byte) (dest []byte) { return StdEncoding.Encode(src) }
+
+// EncodeStringToString encodes the bytes of a string with StdEncoding into a
+// base32k string, see Encoding.EncodeStringToString.
+func EncodeStringToString(s string) (dest string) { return StdEncoding.EncodeStringToString(s) }
 
 // Decode decodes a given base32k byte array back into a binary data byte
-// array.
-func Decode(src []byte) (dest []byte, err error) { return decode(src) }
+// array. Empty input decodes to an empty (nil) result without error. A leading
+// byte order mark, which editors may add when saving the encoding to a file,
+// is skipped.
+func Decode(src []byte) (dest []byte, err error) { return StdEncoding.Decode(src) }
 
 // EncodeToString encodes a given byte array of data into a base32k string.
-func EncodeToString(src []byte) (dest string) { return string(encode(src)) }
+func EncodeToString(src []byte) (dest string) { return StdEncoding.EncodeToString(src) }
 
 // DecodeFromString decodes a given base32k string back into a binary data
 // byte array.
-func DecodeFromString(s string) (dest []byte, err error) { return decode([]byte(s)) }
+func DecodeFromString(s string) (dest []byte, err error) { return StdEncoding.DecodeFromString(s) }
 
-func encode(src []byte) (dest []byte) {
+// EncodeToRunes encodes a given byte array of data into a slice of base32k
+// runes, skipping the UTF-8 serialization of the glyphs.
+func EncodeToRunes(src []byte) (dest []rune) { return StdEncoding.EncodeToRunes(src) }
+
+// DecodeFromRunes decodes a given slice of base32k runes back into a binary
+// data byte array.
+func DecodeFromRunes(src []rune) (dest []byte, err error) { return StdEncoding.DecodeFromRunes(src) }
+
+// EqualEncoded reports whether the base32k byte arrays a and b decode to the
+// same data, regardless of how their trailing symbols are spelled. Invalid
+// encodings are never equal to anything, not even themselves.
+func EqualEncoded(a, b []byte) bool {
+	// Valid encodings of data of different lengths differ in their number of
+	// glyphs or padding symbol, which settles it without decoding.
+	if StdEncoding.impliedLength(a) != StdEncoding.impliedLength(b) {
+		return false
+	}
+	decodedA, err := StdEncoding.Decode(a)
+	if err != nil {
+		return false
+	}
+	decodedB, err := StdEncoding.Decode(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(decodedA, decodedB)
+}
+
+// Glyphs walks the encoding of src glyph by glyph and calls yield for each
+// glyph with its bit offset into src, its raw 15-bit value and the resulting
+// rune including the lane prefix. The trailing padding symbol is not a glyph
+// and is not reported. The walk stops early if yield returns false.
+func Glyphs(src []byte, yield func(index int, value uint16, r rune) bool) {
+	StdEncoding.walkGlyphs(src, yield)
+}
+
+// LaneHistogram counts how many glyphs of the encoding of src land in each
+// lane. The lanes are indexed by the top 3 bits of the glyph values, in the
+// order of the lane table, so e.g. the count at index 2 is that of the glyphs
+// remapped to U+4000 - U+4FFF (see toLane). The padding symbol is not counted.
+func LaneHistogram(src []byte) (histogram [8]int) { return StdEncoding.LaneHistogram(src) }
+
+// LaneHistogram counts how many glyphs of the encoding of src land in each
+// lane, see the package-level LaneHistogram. Encodings with fewer lanes leave
+// the remaining counts at 0.
+func (enc *Encoding) LaneHistogram(src []byte) (histogram [8]int) {
+	shift := 12
+	if enc.bmp {
+		shift = 13 // split into eighths of the glyph values
+	}
+	enc.walkGlyphs(src, func(_ int, value uint16, _ rune) bool {
+		histogram[value>>shift] += 1
+		return true
+	})
+	return
+}
+
+// Encode encodes a given byte array of data into a base32k byte array. Empty
+// input results in an empty result even if the encoding requires non-empty
+// input, use EncodeChecked to get an error in that case.
+func (enc *Encoding) Encode(src []byte) (dest []byte) {
 	if len(src) == 0 {
 		return
 	}
+	if table := enc.tableFor(); table != nil {
+		return appendEncode(enc, table, nil, src)
+	}
 	var destBuf bytes.Buffer
-	destBuf.Grow(EncodedLength(len(src)))
-	r, i, b, d := uint16(0), uint(0), uint(0), uint(0)
-	var err error
+	destBuf.Grow(enc.EncodedLength(len(src)))
+	enc.encodeRunes(src, func(r rune) { destBuf.WriteRune(r) })
+	return destBuf.Bytes()
+}
+
+// EncodeChecked is like Encode, but returns an error if src violates the
+// input constraints of the encoding: ErrEmptyInput for empty input if the
+// encoding was created with RequireNonEmpty, and ErrInputTooLarge for input
+// above the limit set with WithMaxInputBytes.
+func (enc *Encoding) EncodeChecked(src []byte) (dest []byte, err error) {
+	if err = enc.checkInput(len(src)); err != nil {
+		return nil, err
+	}
+	return enc.Encode(src), nil
+}
+
+// EncodeToString encodes a given byte array of data into a base32k string. The
+// string is built in place, sized with EncodedByteLength, instead of being
+// copied from the result of Encode.
+func (enc *Encoding) EncodeToString(src []byte) (dest string) {
+	if len(src) == 0 {
+		return
+	}
+	var destBuf strings.Builder
+	destBuf.Grow(enc.EncodedByteLength(len(src)))
+	enc.encodeRunes(src, func(r rune) { destBuf.WriteRune(r) })
+	return destBuf.String()
+}
+
+// EncodeStringToString encodes the bytes of a string, e.g. a UTF-8 message,
+// into a base32k string, like EncodeToString but without copying s into a
+// byte array first.
+func (enc *Encoding) EncodeStringToString(s string) (dest string) {
+	if len(s) == 0 {
+		return
+	}
+	var destBuf strings.Builder
+	destBuf.Grow(enc.EncodedByteLength(len(s)))
+	encodeRunes(enc, s, func(r rune) { destBuf.WriteRune(r) })
+	return destBuf.String()
+}
+
+// EncodeToRunes encodes a given byte array of data into a slice of base32k
+// runes, skipping the UTF-8 serialization of the glyphs.
+func (enc *Encoding) EncodeToRunes(src []byte) (dest []rune) {
+	if len(src) == 0 {
+		return
+	}
+	dest = make([]rune, 0, enc.EncodedLength(len(src)))
+	enc.encodeRunes(src, func(r rune) { dest = append(dest, r) })
+	return dest
+}
+
+// Decode decodes a given base32k byte array back into a binary data byte
+// array.
+func (enc *Encoding) Decode(src []byte) (dest []byte, err error) {
+	if enc.trimSpace {
+		src = bytes.TrimRight(src, asciiSpace)
+	}
+	return decode(enc, src)
+}
+
+// decode is Encoding.Decode for a byte array or a string, without trimming
+// the input.
+func decode[S bytesOrString](enc *Encoding, src S) (dest []byte, err error) {
+	if err = enc.checkInput(len(src)); err != nil || len(src) == 0 {
+		return nil, err
+	}
+	d := newDecoder(enc)
+	last, _ := utf8.DecodeLastRune([]byte(src[max(len(src)-utf8.UTFMax, 0):]))
+	d.out = make([]byte, 0, enc.decodeHint(runeCount(src), last))
+	if err = decodeUTF8(&d, src); err != nil {
+		return []byte{}, err
+	}
+	return d.out, nil
+}
+
+// DecodeInto decodes a given base32k byte array with StdEncoding into dst,
+// see Encoding.DecodeInto.
+func DecodeInto(dst, src []byte) (n int, err error) { return StdEncoding.DecodeInto(dst, src) }
+
+// DecodeInto decodes a given base32k byte array into dst and returns the
+// number of bytes written. If dst is too small to hold the decoded data,
+// ErrShortBuffer is returned. MaxDecodedLen gives a sufficient size, if the
+// exact length is not known.
+func (enc *Encoding) DecodeInto(dst, src []byte) (n int, err error) {
+	if enc.trimSpace {
+		src = bytes.TrimRight(src, asciiSpace)
+	}
+	d := newDecoder(enc)
+	d.out, d.fixed = dst[:0:len(dst)], true
+	err = d.decodeUTF8(src)
+	return len(d.out), err
+}
+
+// DecodeFromString decodes a given base32k string back into a binary data
+// byte array.
+func (enc *Encoding) DecodeFromString(s string) (dest []byte, err error) {
+	if enc.trimSpace {
+		s = strings.TrimRight(s, asciiSpace)
+	}
+	// Decode and DecodeFromString share the decoding, which reads the runes
+	// straight from the input, so neither copies it or counts its runes with
+	// package utf8 first.
+	return decode(enc, s)
+}
+
+// DecodeFromRunes decodes a given slice of base32k runes back into a binary
+// data byte array.
+func (enc *Encoding) DecodeFromRunes(src []rune) (dest []byte, err error) {
+	for enc.trimSpace && len(src) > 0 && strings.ContainsRune(asciiSpace, src[len(src)-1]) {
+		src = src[:len(src)-1]
+	}
+	if err = enc.checkInput(len(src)); err != nil || len(src) == 0 {
+		return nil, err
+	}
+	d := newDecoder(enc)
+	d.out = make([]byte, 0, enc.MaxDecodedLen(len(src)))
+	for i, r := range src {
+		if err = d.decodeRune(i, r, i == len(src)-1); err != nil {
+			return []byte{}, err
+		}
+	}
+	if err = d.finish(); err != nil {
+		return []byte{}, err
+	}
+	return d.out, nil
+}
+
+// encodeRunes runs the encoding loop over src and hands every resulting rune,
+// including the leading marker and the trailing padding symbol, to emit.
+func (enc *Encoding) encodeRunes(src []byte, emit func(r rune)) { encodeRunes(enc, src, emit) }
+
+// encodeRunes is Encoding.encodeRunes for a byte array or a string.
+func encodeRunes[S bytesOrString](enc *Encoding, src S, emit func(r rune)) {
+	enc.encodePrefix(len(src), emit)
+	d := walkGlyphs(enc, src, func(_ int, value uint16, r rune) bool {
+		enc.emitGlyph(value, r, emit)
+		return true
+	})
+	if d > 0 && enc.HasPadding() {
+		emit(enc.padStart + rune(d))
+	}
+}
+
+// encodePrefix hands the runes that precede the glyphs of srcLength bytes of
+// data to emit: the marker and the length glyph, if enabled.
+func (enc *Encoding) encodePrefix(srcLength int, emit func(r rune)) {
+	if srcLength == 0 {
+		return
+	}
+	if enc.marker {
+		emit(MARKER_SYMBOL)
+	}
+	if enc.lengthGlyph {
+		value := uint16(srcLength % int(enc.bitsPerRune))
+		enc.emitGlyph(value, enc.valueToRune(value), emit)
+	}
+}
+
+// emitGlyph hands the glyph r for value to emit, or its escape sequence if r
+// is excluded.
+func (enc *Encoding) emitGlyph(value uint16, r rune, emit func(r rune)) {
+	if enc.isExcluded(r) {
+		emit(escapeHigh + rune(value>>8))
+		emit(escapeLow + rune(value&0xff))
+	} else {
+		emit(r)
+	}
+}
+
+// withoutPrefix returns enc itself if it doesn't prefix the glyphs, or a copy
+// that doesn't, for encoding data in pieces behind a single prefix.
+func (enc *Encoding) withoutPrefix() *Encoding {
+	if !enc.marker && !enc.lengthGlyph {
+		return enc
+	}
+	unprefixed := *enc
+	unprefixed.marker, unprefixed.lengthGlyph = false, false
+	return &unprefixed
+}
+
+// walkGlyphs is the encoding loop behind Glyphs. It returns the number of
+// data bits in the final glyph (the padding digit), or 0 if there is no
+// partial final glyph or yield stopped the walk.
+func (enc *Encoding) walkGlyphs(src []byte, yield func(index int, value uint16, r rune) bool) (digits uint) {
+	return walkGlyphs(enc, src, yield)
+}
+
+// walkGlyphs is Encoding.walkGlyphs for a byte array or a string.
+func walkGlyphs[S bytesOrString](enc *Encoding, src S, yield func(index int, value uint16, r rune) bool) (digits uint) {
+	br := bitReader[S]{src: src, width: enc.bitsPerRune}
 	for {
-		r, i, b, err = getRuneFromBytes(src, i, b)
-		if err != nil {
+		index := br.offset()
+		value, ok := br.read()
+		if !ok {
 			break
 		}
-		prefix := toLane[r>>12]
-		r = r&0x0fff | prefix
-		destBuf.WriteRune(rune(r))
-	}
-	r, d, err = getLastRune(src, i, b)
-	if err == nil {
-		prefix := toLane[r>>12]
-		r = r&0x0fff | prefix
-		destBuf.WriteRune(rune(r))
-		if d > 0 {
-			destBuf.WriteRune(PAD_START_SYMBOL + rune(d))
+		if !yield(index, value, enc.valueToRune(value)) {
+			return 0
 		}
 	}
-	return destBuf.Bytes()
+	index := br.offset()
+	value, digits, ok := br.readLast()
+	if !ok || !yield(index, value, enc.valueToRune(value)) {
+		return 0
+	}
+	return digits
 }
 
-func getRuneFromBytes(src []byte, index uint, bit uint) (value uint16, newIndex uint, newBit uint, err error) {
-	if index+2 == uint(len(src)) && bit > 1 ||
-		index+2 > uint(len(src)) {
-		return 0, index, bit, errors.New("End of input")
+// EncodeRune returns the StdEncoding glyph for the 15-bit value, see
+// Encoding.EncodeRune.
+func EncodeRune(value uint16) rune { return StdEncoding.EncodeRune(value) }
+
+// DecodeRune returns the 15-bit value of a StdEncoding glyph, see
+// Encoding.DecodeRune.
+func DecodeRune(r rune) (value uint16, ok bool) { return StdEncoding.DecodeRune(r) }
+
+// EncodeRune returns the glyph for a single glyph value, i.e. the lowest
+// bitsPerRune bits of value. Higher bits are ignored.
+func (enc *Encoding) EncodeRune(value uint16) rune {
+	return enc.valueToRune(value & (1<<enc.bitsPerRune - 1))
+}
+
+// DecodeRune returns the value of the glyph r, the reverse of EncodeRune. It
+// returns false if r is not a glyph of the encoding, which includes the
+// padding symbols.
+func (enc *Encoding) DecodeRune(r rune) (value uint16, ok bool) {
+	if enc.bmp {
+		if r >= surrogateEnd {
+			r -= surrogateEnd - surrogateStart
+		} else if r >= surrogateStart {
+			return 0, false
+		}
+		if r < bmpOffset || r-bmpOffset > 0xffff {
+			return 0, false
+		}
+		return uint16(r - bmpOffset), true
 	}
-	value = uint16(src[index] >> bit)
-	value += uint16(src[index+1]) << (BYTE_LEN - bit)
-	if bit > 1 { // we skipped more than 1 bit of the first byte & thus need some of the third byte as well
-		value += uint16(src[index+2]) << (BYTE_LEN*2 - bit)
+	if !enc.isGlyph(r) {
+		return 0, false
 	}
-	value &= 0x7fff
-	if bit != 0 {
-		newIndex = index + 2
-	} else {
-		newIndex = index + 1
+	return uint16(r)&0x0fff + uint16(enc.fromLane[r>>12])<<12, true
+}
+
+// valueToRune maps a glyph value to its rune by replacing the top bits with
+// the lane prefix.
+func (enc *Encoding) valueToRune(value uint16) rune {
+	if enc.bmp {
+		r := rune(value) + bmpOffset
+		if r >= surrogateStart {
+			r += surrogateEnd - surrogateStart
+		}
+		return r
 	}
-	newBit = (bit + BITS_PER_RUNE) % BYTE_LEN
-	return
+	prefix := enc.toLane[value>>12]
+	return rune(value&0x0fff | prefix)
 }
 
-func getLastRune(src []byte, index uint, bit uint) (value uint16, digits uint, err error) {
-	switch uint(len(src)) - index {
-	case 2:
-		value = uint16(src[index] >> bit)
-		value += uint16(src[index+1]) << (BYTE_LEN - bit)
-		digits = BITS_PER_RUNE + 1 - bit
-	case 1:
-		value = uint16(src[index] >> bit)
-		digits = BITS_PER_RUNE + 1 - BYTE_LEN - bit
-	default:
-		err = errors.New("End of input reached")
+// WithPadding creates a new encoding identical to enc, except that its padding
+// symbols start at padding instead of PAD_START_SYMBOL, i.e. range from
+// padding+1 to padding+14 for StdEncoding. All padding symbols must lie below
+// U+1000, outside of the glyph lanes. NoPadding disables the padding symbol
+// altogether, which makes only inputs whose lengths are multiples of 15 bytes
+// decodable.
+//
+// For backward compatibility the decoder still accepts the padding symbols
+// starting at PAD_START_SYMBOL, so that data encoded before the padding was
+// changed remains decodable. Where the two ranges overlap, a symbol is read
+// as one of the configured padding. Use StrictPadding to accept only the
+// configured padding. Without padding, no padding symbol is accepted.
+func (enc Encoding) WithPadding(padding rune) *Encoding {
+	firstGlyph := rune(0x1000)
+	if enc.bmp {
+		firstGlyph = bmpOffset
 	}
-	return
+	if padding != NoPadding && (padding < 0 || padding+rune(enc.bitsPerRune) > firstGlyph) {
+		panic("invalid padding")
+	}
+	enc.padStart = padding
+	if padding != NoPadding {
+		enc.lengthGlyph = false
+	}
+	return &enc
 }
 
-func decode(src []byte) (data []byte, err error) {
-	if len(src) == 0 {
-		return
+// HasPadding reports whether the encoding ends the glyphs with a padding
+// symbol, i.e. wasn't created with WithPadding(NoPadding). Without padding,
+// only data whose glyphs end on a byte boundary can be decoded, which is
+// always the case for lengths that are multiples of 15 bytes.
+func (enc *Encoding) HasPadding() bool { return enc.padStart != NoPadding }
+
+// Clone returns a deep copy of enc, which shares no state with enc and can be
+// customized without affecting it.
+func (enc *Encoding) Clone() *Encoding {
+	clone := *enc
+	clone.toLane = append([]uint16(nil), enc.toLane...)
+	clone.fromLane = append([]byte(nil), enc.fromLane...)
+	clone.exclusions = append([]RuneRange(nil), enc.exclusions...)
+	if enc.table != nil {
+		clone.table = &glyphTable{}
 	}
-	runes := bytes.Runes(src)
-	var destBuf bytes.Buffer
-	destBuf.Grow(DecodedLength(len(src), src[len(src)-1]))
-	data, remainder, b := []byte{}, byte(0), uint(0)
-	for i, r := range runes {
-		prefix := fromLane[r>>12]
-		if prefix == 0xff {
-			return []byte{}, errors.New(fmt.Sprintf(
-				"Invalid character at position %d: %s", i, string(r),
-			))
-		} else if prefix == 0xfe {
-			if r <= PAD_START_SYMBOL && r >= (PAD_START_SYMBOL+BITS_PER_RUNE) || i != len(runes)-1 {
-				return []byte{}, errors.New(fmt.Sprintf(
-					"Invalid character or misplaced padding character at position %d: %s", i, string(r),
-				))
+	return &clone
+}
+
+// WithNFDRecovery creates a new encoding identical to enc, except that its
+// decoder recomposes Hangul syllables that have been decomposed into their
+// conjoining jamo, e.g. by NFD normalization during transport. Without it,
+// decoding fails at the first jamo.
+//
+// Only the Hangul lanes (U+B000 - U+CFFF) of StdEncoding are affected by
+// normalization: they are stable under NFC and NFKC, but decompose under NFD
+// and NFKD. The CJK lanes and the padding symbols are stable under all four
+// normalization forms, and SafeEncoding uses only CJK lanes.
+func (enc Encoding) WithNFDRecovery() *Encoding {
+	enc.recoverNFD = true
+	return &enc
+}
+
+// StrictPadding creates a new encoding identical to enc, except that its
+// decoder only accepts its own padding symbols and not the ones starting at
+// PAD_START_SYMBOL, see WithPadding.
+func (enc Encoding) StrictPadding() *Encoding {
+	enc.strictPad = true
+	return &enc
+}
+
+// TrimTrailingSpace creates a new encoding identical to enc, except that its
+// decoding functions ignore any ASCII whitespace at the end of the input, such
+// as the newline after the output of the command line tool. The padding symbol
+// is then the last rune before the whitespace. The stream decoders don't trim
+// their input.
+func (enc Encoding) TrimTrailingSpace() *Encoding {
+	enc.trimSpace = true
+	return &enc
+}
+
+// asciiSpace is the whitespace removed by TrimTrailingSpace.
+const asciiSpace = " \t\n\v\f\r"
+
+// RequireNonEmpty creates a new encoding identical to enc, except that it
+// rejects empty input with ErrEmptyInput, for protocols where an empty
+// message is an error. This applies to all decoding functions and to
+// EncodeChecked, the other encoding functions can't report errors and keep
+// encoding empty input to an empty result.
+func (enc Encoding) RequireNonEmpty() *Encoding {
+	enc.nonEmpty = true
+	return &enc
+}
+
+// WithMaxInputBytes creates a new encoding identical to enc, except that it
+// rejects input longer than limit with ErrInputTooLarge before allocating
+// anything for it, guarding services against memory exhaustion by huge
+// inputs. A limit of 0 means no limit, which is the default.
+//
+// The limit applies to the length in bytes of the input of EncodeChecked and
+// of the decoding functions that take the whole input at once, and to the
+// number of runes for DecodeFromRunes. The stream decoders, which don't hold
+// their input, and DecodeInto, which doesn't allocate, are not limited.
+func (enc Encoding) WithMaxInputBytes(limit int) *Encoding {
+	if limit < 0 {
+		panic("invalid input limit")
+	}
+	enc.maxInput = limit
+	return &enc
+}
+
+// checkInput checks the length of the input of an encoding or decoding
+// function against the constraints of the encoding.
+func (enc *Encoding) checkInput(length int) error {
+	if enc.nonEmpty && length == 0 {
+		return ErrEmptyInput
+	}
+	if enc.maxInput > 0 && length > enc.maxInput {
+		return ErrInputTooLarge
+	}
+	return nil
+}
+
+// isGlyph reports whether r is a data glyph of the encoding.
+func (enc *Encoding) isGlyph(r rune) bool { return enc.lane(r) < 0xfe }
+
+// lane returns the fromLane entry for r, or 0xfe for the code points below
+// the glyphs and 0 for glyphs of BMPEncoding, which has no lanes.
+func (enc *Encoding) lane(r rune) byte {
+	if enc.bmp {
+		if r >= 0 && r < bmpOffset {
+			return 0xfe
+		} else if _, ok := enc.DecodeRune(r); ok {
+			return 0
+		}
+		return 0xff
+	}
+	if r < 0 || int(r>>12) >= len(enc.fromLane) {
+		return 0xff
+	}
+	return enc.fromLane[r>>12]
+}
+
+// invalidReason describes why r, which is neither a glyph nor in lane 0, isn't
+// part of the encoding: usually it lies in one of the lanes that fromLane
+// marks as invalid, which tells non-base32k text apart from corrupted glyphs.
+func (enc *Encoding) invalidReason(r rune) string {
+	if r >= surrogateStart && r < surrogateEnd {
+		return "Unpaired surrogate half"
+	}
+	if enc.bmp || r < 0 || r > 0xffff {
+		return "Invalid character"
+	}
+	return fmt.Sprintf("Character %U in lane 0x%x, which the encoding doesn't use", r, r>>12)
+}
+
+// invalidSequence returns the error for the invalid UTF-8 that starts src, at
+// rune index i of the input: ErrUnexpectedEnd for the start of a rune that is
+// cut off by the end of the input, and a CorruptInputError for an encoded
+// surrogate half or any other invalid sequence.
+func invalidSequence[S bytesOrString](i int, src S) error {
+	sequence := []byte(src[:min(len(src), utf8.UTFMax)])
+	if !utf8.FullRune(sequence) {
+		return ErrUnexpectedEnd
+	}
+	if surrogate, ok := surrogateHalf(sequence); ok {
+		return CorruptInputError{i, surrogate, "Unpaired surrogate half"}
+	}
+	return CorruptInputError{i, utf8.RuneError, "Invalid UTF-8 sequence"}
+}
+
+// surrogateHalf returns the surrogate code point whose three-byte sequence,
+// which isn't valid UTF-8 but is what CESU-8 and WTF-8 produce for an unpaired
+// half, starts src.
+func surrogateHalf(src []byte) (r rune, ok bool) {
+	if len(src) < 3 || src[0] != 0xed || src[1] < 0xa0 || src[1] > 0xbf || src[2]&0xc0 != 0x80 {
+		return 0, false
+	}
+	return rune(src[0]&0x0f)<<12 | rune(src[1]&0x3f)<<6 | rune(src[2]&0x3f), true
+}
+
+// isPadding reports whether r is a valid padding symbol of the encoding.
+func (enc *Encoding) isPadding(r rune) bool {
+	padStart := enc.paddingStart(r)
+	return enc.HasPadding() && r > padStart && r < padStart+rune(enc.bitsPerRune)
+}
+
+// paddingStart returns the start of the padding symbols that r is read as,
+// which is the configured padding unless r is only a legacy padding symbol.
+func (enc *Encoding) paddingStart(r rune) rune {
+	width := rune(enc.bitsPerRune)
+	if enc.strictPad || !enc.HasPadding() || (r > enc.padStart && r < enc.padStart+width) {
+		return enc.padStart
+	}
+	if r > PAD_START_SYMBOL && r < PAD_START_SYMBOL+width {
+		return PAD_START_SYMBOL
+	}
+	return enc.padStart
+}
+
+// decoder holds the state of the decoding loop between runes: the decoded
+// data and the bits carried over from the previous glyph.
+type decoder struct {
+	enc  *Encoding
+	out  []byte
+	bits bitWriter
+	// fixed is set if out must not grow beyond its capacity. Decoded bytes
+	// that don't fit are kept in overflow, in case a padding symbol removes
+	// them again.
+	fixed    bool
+	overflow []byte
+	// discard is set if the decoded data isn't needed, only the errors.
+	discard bool
+	// replace is set if invalid runes are to be decoded as if they were a
+	// glyph of replacement bytes, see DecodeReplacing.
+	replace       bool
+	replacement   byte
+	substitutions int
+	// misplaced is set when a padding symbol is followed by another rune. The
+	// error is reported once that rune shows whether the padding symbol was
+	// misplaced or is the start of trailing text.
+	misplaced    bool
+	padding      rune
+	paddingIndex int
+	padded       bool
+	// jamo holds a Hangul syllable that is being recomposed from its jamo,
+	// which started at rune index jamoIndex.
+	jamo      rune
+	jamoIndex int
+	// last is the value of the glyph decoded last, out of glyphs in total,
+	// not counting a length glyph.
+	last   uint16
+	glyphs int
+	// length is the value of the length glyph, once lengthRead is set.
+	length     uint16
+	lengthRead bool
+	// started is set once the first rune has been decoded.
+	started bool
+	// bom is set if the input starts with a byte order mark, which the
+	// marker may follow, and marked once the marker has been skipped.
+	bom, marked bool
+	// separator holds the runes that are skipped between the glyphs, see
+	// DecodeFromStringSep.
+	separator string
+	// escape holds the first rune of an escape sequence for an excluded
+	// glyph, which started at rune index escapeIndex.
+	escape      rune
+	escapeIndex int
+}
+
+// newDecoder returns a decoder for enc with empty state.
+func newDecoder(enc *Encoding) decoder {
+	return decoder{enc: enc, bits: bitWriter{width: enc.bitsPerRune}}
+}
+
+// decodeUTF8 decodes all runes of the UTF-8 byte array src.
+func (d *decoder) decodeUTF8(src []byte) error { return decodeUTF8(d, src) }
+
+// decodeUTF8 is decoder.decodeUTF8 for a byte array or a string.
+func decodeUTF8[S bytesOrString](d *decoder, src S) error {
+	for i, pos := 0, 0; pos < len(src); i++ {
+		// utf8.DecodeRune yields RuneError for truncated, overlong and
+		// otherwise non-minimal sequences, none of which an encoder emits.
+		// A RuneError of full size is an actual U+FFFD, which is a glyph of
+		// BMPEncoding. A sequence cut off by the end of the input is
+		// truncated, rather than invalid, and an encoded surrogate half is
+		// reported as such.
+		r, size := decodeRuneAt(src, pos)
+		if r == utf8.RuneError && size == 1 && !d.replace {
+			return invalidSequence(i, src[pos:])
+		}
+		pos += size
+		if err := d.decodeRune(i, r, pos == len(src)); err != nil {
+			return err
+		}
+	}
+	return d.finish()
+}
+
+// decodeRuneAt is utf8.DecodeRune for the rune at pos of a byte array or a
+// string. The ASCII padding symbols and the three-byte glyphs of StdEncoding
+// and SafeEncoding are decoded right here, anything else by package utf8.
+func decodeRuneAt[S bytesOrString](src S, pos int) (r rune, size int) {
+	b := src[pos]
+	if b < utf8.RuneSelf {
+		return rune(b), 1
+	}
+	if b&0xf0 == 0xe0 && pos+2 < len(src) && src[pos+1]&0xc0 == 0x80 && src[pos+2]&0xc0 == 0x80 {
+		r = rune(b&0x0f)<<12 | rune(src[pos+1]&0x3f)<<6 | rune(src[pos+2]&0x3f)
+		if r >= 0x800 && (r < surrogateStart || r >= surrogateEnd) {
+			return r, 3
+		}
+	}
+	return utf8.DecodeRune([]byte(src[pos:min(pos+utf8.UTFMax, len(src))]))
+}
+
+// finish checks the state at the end of the input. Without a padding symbol
+// the glyphs must add up to whole bytes, which is true for any input whose
+// length is a multiple of 15 bytes. Anything else is a truncated encoding.
+func (d *decoder) finish() error {
+	if !d.started {
+		return d.enc.checkInput(0)
+	}
+	if d.escape != 0 {
+		return CorruptInputError{d.escapeIndex, d.escape, "Incomplete escape sequence"}
+	}
+	if err := d.flushJamo(); err != nil {
+		return err
+	}
+	if d.enc.lengthGlyph {
+		if err := d.trimToLength(); err != nil {
+			return err
+		}
+	} else if !d.padded && d.bits.bit != 0 {
+		return ErrUnexpectedEnd
+	}
+	if len(d.overflow) > 0 {
+		return ErrShortBuffer
+	}
+	return nil
+}
+
+// decodeRune decodes the rune r found at rune index i of the input and
+// appends the resulting bytes to the output. last reports whether r is the
+// final rune of the input, which is the only place a padding symbol may go.
+func (d *decoder) decodeRune(i int, r rune, last bool) error {
+	if d.separator != "" && strings.ContainsRune(d.separator, r) {
+		return nil
+	}
+	// The byte order mark and the marker are recognized before the first
+	// glyph, rather than at fixed rune indexes, as separators may precede
+	// them.
+	if !d.started && !d.bom && !d.marked && d.enc.isByteOrderMark(r) {
+		d.bom = true
+		return nil
+	}
+	if !d.started && !d.marked && d.enc.isMarker(r) {
+		d.marked = true
+		return nil
+	}
+	d.started = true
+	if d.enc.recoverNFD {
+		return d.recomposeRune(i, r, last)
+	}
+	return d.decodeGlyph(i, r, last)
+}
+
+// decodeGlyph decodes a single rune, see decodeRune.
+func (d *decoder) decodeGlyph(i int, r rune, last bool) error {
+	if d.enc.isEscape(r) && !d.misplaced {
+		return d.decodeEscape(i, r)
+	} else if d.escape != 0 {
+		return CorruptInputError{d.escapeIndex, d.escape, "Incomplete escape sequence"}
+	}
+	prefix := d.enc.lane(r)
+	if d.replace && prefix >= 0xfe && !(last && d.enc.isPadding(r)) {
+		return d.substitute()
+	}
+	if d.misplaced {
+		if prefix == 0xfe {
+			return CorruptInputError{i, r, "Unexpected text after padding character"}
+		}
+		return CorruptInputError{
+			d.paddingIndex, d.padding, "Invalid character or misplaced padding character",
+		}
+	}
+	if prefix == 0xff {
+		if isJamo(r) {
+			return CorruptInputError{i, r, "Decomposed Hangul jamo (NFD-normalized input?)"}
+		}
+		return CorruptInputError{i, r, d.enc.invalidReason(r)}
+	} else if prefix == 0xfe {
+		if !d.enc.HasPadding() {
+			return CorruptInputError{i, r, "Invalid character"}
+		}
+		if !last {
+			d.misplaced, d.padding, d.paddingIndex = true, r, i
+			return nil
+		}
+		padStart := d.enc.paddingStart(r)
+		if r == padStart {
+			return CorruptInputError{i, r, "Padding character for a glyph without data bits"}
+		}
+		if r < padStart || r >= (padStart+rune(d.enc.bitsPerRune)) {
+			return CorruptInputError{
+				i, r, "Invalid character or misplaced padding character",
 			}
-			padding := BITS_PER_RUNE - (r - PAD_START_SYMBOL)
-			if padding >= 8 {
-				destBuf.Truncate(destBuf.Len() - 1)
+		}
+		// A padding symbol on its own, e.g. what is left of a truncated
+		// encoding, has no glyph to pad.
+		if d.glyphs == 0 {
+			return CorruptInputError{i, r, "Padding character without preceding glyphs"}
+		}
+		d.padded = true
+		// The unused bits of the final glyph are any carried bits that don't
+		// make up a full byte, plus possibly the full byte that is dropped.
+		padding := rune(d.enc.bitsPerRune) - (r - padStart)
+		if padding < 0 || padding%BYTE_LEN != rune(d.bits.bit) {
+			return CorruptInputError{i, r, "Padding character inconsistent with preceding glyphs"}
+		}
+		// The encoder leaves the unused bits zero, so data with other unused
+		// bits has no canonical encoding. Substituted glyphs are exempt.
+		if d.last>>(r-padStart) != 0 && !d.replace {
+			return CorruptInputError{i, r, "Unused bits of the final glyph set"}
+		}
+		if padding >= 8 && !d.discard {
+			if len(d.overflow) > 0 {
+				d.overflow = d.overflow[:len(d.overflow)-1]
+			} else {
+				d.out = d.out[:len(d.out)-1]
 			}
-			break
 		}
-		value := uint16(r)&0x0fff + uint16(prefix)<<12
-		data, remainder, b = getBytesFromRune(value, remainder, b)
-		destBuf.Write(data)
+		return nil
 	}
-	return destBuf.Bytes(), nil
+	value, _ := d.enc.DecodeRune(r)
+	return d.writeValue(value)
 }
 
-func getBytesFromRune(value uint16, remainder byte, bit uint) (data []byte, newRemainder byte, newBit uint) {
-	data = []byte{}
-	data = append(data, byte(value<<bit)+remainder)
-	if bit != 0 { // a complete second byte is available
-		data = append(data, byte(value>>(BYTE_LEN-bit)))
-		newRemainder = byte(value >> (BYTE_LEN*2 - bit))
-	} else {
-		newRemainder = byte(value >> BYTE_LEN)
+// write appends data to the output. If the output is fixed and data doesn't
+// fit, the rest is held back in overflow: only the final glyph may produce a
+// byte that is later removed by the padding symbol, any further data is an
+// error.
+func (d *decoder) write(data []byte) error {
+	if d.discard {
+		return nil
 	}
-	newBit = (bit + BITS_PER_RUNE) % BYTE_LEN
-	return
+	if !d.fixed {
+		d.out = append(d.out, data...)
+		return nil
+	}
+	if len(d.overflow) > 0 {
+		return ErrShortBuffer
+	}
+	if room := cap(d.out) - len(d.out); len(data) > room {
+		d.overflow = append(d.overflow, data[room:]...)
+		data = data[:room]
+	}
+	d.out = append(d.out, data...)
+	return nil
+}
+
+// writeValue appends the bytes completed by the glyph value to the output.
+func (d *decoder) writeValue(value uint16) error {
+	if d.enc.lengthGlyph && !d.lengthRead {
+		d.length, d.lengthRead = value, true
+		return nil
+	}
+	d.last, d.glyphs = value, d.glyphs+1
+	data, n := d.bits.write(value)
+	return d.write(data[:n])
 }
 
 // EncodedLength returns the length of the encoded string in characters. It
 // does an integer ceiling(!) division of the bit-length of src.
 // See: Warren Jr., Henry S. "Hacker's Delight" Pearson 2003 (14th printing
 // 2011) p. 139
-func EncodedLength(srcLength int) (length int) {
-	rawLength := (srcLength*BYTE_LEN + BITS_PER_RUNE - 1) / BITS_PER_RUNE
-	padded := srcLength%BITS_PER_RUNE != 0
-	if padded {
+//
+// The division is split into whole 15-byte blocks and a remainder, so that
+// the bit-length is never formed as an intermediate product, which would
+// overflow int for large inputs (~256 MB on 32-bit platforms).
+func EncodedLength(srcLength int) (length int) { return StdEncoding.EncodedLength(srcLength) }
+
+// EncodedLength returns the length of the encoded string in characters, see
+// the package-level EncodedLength. Without padding, see HasPadding, there is
+// no padding symbol to count.
+func (enc *Encoding) EncodedLength(srcLength int) (length int) {
+	width := int(enc.bitsPerRune)
+	blocks, rest := srcLength/width, srcLength%width
+	rawLength := blocks*BYTE_LEN + (rest*BYTE_LEN+width-1)/width
+	if enc.marker && srcLength > 0 {
+		rawLength += 1
+	}
+	if enc.lengthGlyph && srcLength > 0 {
+		rawLength += 1
+	}
+	padded := rest*BYTE_LEN%width != 0
+	if padded && enc.HasPadding() {
 		return rawLength + 1
 	} else {
 		return rawLength
 	}
 }
 
+// EncodedByteLength returns the exact length in bytes of the encoding of
+// srcLength bytes of data, e.g. for writing a length prefix before streaming
+// it. Unlike EncodedLength it accounts for the glyphs taking up 3 bytes of
+// UTF-8 each, while the padding symbol is a single ASCII byte.
+func EncodedByteLength(srcLength int) (length int) { return StdEncoding.EncodedByteLength(srcLength) }
+
+// EncodedByteLength returns the exact length in bytes of the encoding,
+// see the package-level EncodedByteLength. For BMPEncoding, whose glyphs
+// vary in length, it is the maximum length.
+func (enc *Encoding) EncodedByteLength(srcLength int) (length int) {
+	glyphs, padding := enc.encodedRunes(srcLength)
+	length = glyphs * enc.glyphBytes()
+	if padding != NoPadding {
+		length += utf8.RuneLen(padding)
+	}
+	return length
+}
+
+// EncodeStats returns the size of the encoding of src without encoding it:
+// its length in glyphs (including the padding symbol) and in bytes, and the
+// number of tweets of GLYPHS_PER_TWEET glyphs it would take up.
+func EncodeStats(src []byte) (glyphs int, utf8Bytes int, tweetsNeeded int) {
+	return StdEncoding.EncodeStats(src)
+}
+
+// EncodeStats returns the size of the encoding of src without encoding it, see
+// the package-level EncodeStats. The glyphs of BMPEncoding vary in length and
+// are counted one by one.
+func (enc *Encoding) EncodeStats(src []byte) (glyphs int, utf8Bytes int, tweetsNeeded int) {
+	glyphs, padding := enc.encodedRunes(len(src))
+	if padding != NoPadding {
+		glyphs += 1
+	}
+	if enc.bmp {
+		enc.encodeRunes(src, func(r rune) { utf8Bytes += utf8.RuneLen(r) })
+	} else {
+		utf8Bytes = enc.EncodedByteLength(len(src))
+	}
+	tweetsNeeded = (glyphs + GLYPHS_PER_TWEET - 1) / GLYPHS_PER_TWEET
+	return
+}
+
+// PercentEncodedLength returns the length in bytes of the encoded form of
+// srcLength bytes of data after it has been percent-encoded for a URL, as by
+// url.QueryEscape. Every glyph is 3 bytes of UTF-8 and each of those bytes
+// turns into a 3 character "%XX" escape, so a glyph takes up 9 characters,
+// while the ASCII padding symbol is kept as is. This is far worse than base64url
+// (4 characters per 3 bytes), and only meant to help decide which one to use
+// for a given medium, i.e. whether its limit is counted in glyphs or bytes.
+func PercentEncodedLength(srcLength int) (length int) {
+	return StdEncoding.PercentEncodedLength(srcLength)
+}
+
+// PercentEncodedLength returns the length in bytes of the percent-encoded
+// form of the encoding, see the package-level PercentEncodedLength. For
+// BMPEncoding it is the maximum length.
+func (enc *Encoding) PercentEncodedLength(srcLength int) (length int) {
+	glyphs, padding := enc.encodedRunes(srcLength)
+	length = glyphs * enc.glyphBytes() * 3
+	if padding != NoPadding {
+		length += percentEncodedRuneLength(padding)
+	}
+	return length
+}
+
+// glyphBytes returns the length in bytes of the UTF-8 encoding of a glyph,
+// which is at most 4 bytes for BMPEncoding.
+func (enc *Encoding) glyphBytes() int {
+	if enc.bmp {
+		return utf8.UTFMax
+	}
+	return 3
+}
+
+// encodedRunes returns the number of glyphs in the encoding of srcLength bytes
+// of data, not counting the padding symbol, and the padding symbol itself or
+// NoPadding if there is none.
+func (enc *Encoding) encodedRunes(srcLength int) (glyphs int, padding rune) {
+	width := int(enc.bitsPerRune)
+	glyphs = enc.EncodedLength(srcLength)
+	d := srcLength % width * BYTE_LEN % width
+	if d == 0 {
+		return glyphs, NoPadding
+	}
+	if !enc.HasPadding() {
+		return glyphs, NoPadding
+	}
+	return glyphs - 1, enc.padStart + rune(d)
+}
+
+// percentEncodedRuneLength returns the length of r after percent-encoding,
+// where only the unreserved characters of RFC 3986 are left unescaped.
+func percentEncodedRuneLength(r rune) int {
+	switch {
+	case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		return 1
+	case r == '-', r == '.', r == '_', r == '~':
+		return 1
+	}
+	return utf8.RuneLen(r) * 3
+}
+
+// MaxDecodedLen returns the maximum length in bytes of the data resulting from
+// decoding glyphCount glyphs. Unlike DecodedLength it doesn't need to know the
+// padding symbol, so it is suitable for allocating a buffer before the end of
+// the input is known. The decoded data is at most one byte shorter, or a bit
+// more if glyphCount includes the padding symbol.
+func MaxDecodedLen(glyphCount int) (length int) { return StdEncoding.MaxDecodedLen(glyphCount) }
+
+// MaxDecodedLen returns the maximum length in bytes of the data resulting from
+// decoding glyphCount runes, see the package-level MaxDecodedLen.
+func (enc *Encoding) MaxDecodedLen(glyphCount int) (length int) {
+	// Split into blocks of 8 glyphs to avoid overflowing int, like in
+	// EncodedLength.
+	width := int(enc.bitsPerRune)
+	blocks, rest := glyphCount/BYTE_LEN, glyphCount%BYTE_LEN
+	return blocks*width + rest*width/BYTE_LEN
+}
+
+// decodeHint returns the capacity to allocate for decoding count runes, the
+// last of which is last. This is the exact length of valid data, or slightly
+// more if it contains escapes, a marker or a byte order mark, and doesn't
+// validate anything.
+func (enc *Encoding) decodeHint(count int, last rune) int {
+	if !enc.HasPadding() || !enc.isPadding(last) {
+		return enc.MaxDecodedLen(count)
+	}
+	width, full := int(enc.bitsPerRune), max(count-2, 0)
+	bits := full%BYTE_LEN*width + int(last-enc.paddingStart(last))
+	return full/BYTE_LEN*width + bits/BYTE_LEN
+}
+
+// impliedLength returns the length of the data that src decodes to if it is
+// valid, from its number of runes and its final rune. Unlike decodeHint it
+// skips a leading byte order mark and marker, so that it is exact for the
+// encodings without escapes or a length glyph.
+func (enc *Encoding) impliedLength(src []byte) int {
+	if r, size := utf8.DecodeRune(src); enc.isByteOrderMark(r) {
+		src = src[size:]
+	}
+	if r, size := utf8.DecodeRune(src); enc.isMarker(r) {
+		src = src[size:]
+	}
+	if len(src) == 0 {
+		return 0
+	}
+	last, _ := utf8.DecodeLastRune(src)
+	return enc.decodeHint(runeCount(src), last)
+}
+
+// runeCount counts the runes of the valid UTF-8 in src by its leading bytes.
+// utf8.RuneCount copies non-ASCII input, which would double the allocations
+// of Decode.
+func runeCount[S bytesOrString](src S) (count int) {
+	for i := 0; i < len(src); i += 1 {
+		if src[i]&0xc0 != 0x80 {
+			count += 1
+		}
+	}
+	return count
+}
+
 // DecodedLength returns the length of the data in bytes resulting from
-// decoding the source string.
+// decoding the source string. It returns -1 if the source string is padded,
+// but paddingRune isn't a padding symbol, e.g. PAD_START_SYMBOL itself.
 func DecodedLength(srcLength int, paddingRune byte) (length int) {
 	if srcLength == 0 {
 		return 0
@@ -264,6 +1266,9 @@ func DecodedLength(srcLength int, paddingRune byte) (length int) {
 	padded := srcLength%BYTES_PER_RUNE != 0
 	var rawLength, padding int
 	if padded {
+		if !StdEncoding.isPadding(rune(paddingRune)) {
+			return -1
+		}
 		padding = BITS_PER_RUNE - int(rune(paddingRune)-PAD_START_SYMBOL)
 		rawLength = srcLength - 1
 	} else {
@@ -272,3 +1277,59 @@ func DecodedLength(srcLength int, paddingRune byte) (length int) {
 	}
 	return (rawLength*BITS_PER_RUNE + 1 - BITS_PER_RUNE - padding) / BYTE_LEN
 }
+
+// DecodedLenOfString returns the exact length in bytes of the data resulting
+// from decoding the StdEncoding string s, see Encoding.DecodedLenOfString.
+func DecodedLenOfString(s string) (length int, err error) { return StdEncoding.DecodedLenOfString(s) }
+
+// DecodedLenOfString returns the exact length in bytes of the data resulting
+// from decoding s, computed only from its number of glyphs and its final rune,
+// so that a buffer can be allocated before decoding. It returns ErrEmptyInput
+// for an empty string, ErrUnexpectedEnd for unpadded glyphs that don't end on
+// a full byte, and a CorruptInputError if the final rune is neither a glyph
+// nor a padding symbol that fits the number of glyphs. The other runes are
+// counted, but not validated. With a length glyph, see WithLengthGlyph, the
+// length follows from it instead of the final rune, and ErrLengthGlyph is
+// returned if it doesn't fit the number of glyphs.
+func (enc *Encoding) DecodedLenOfString(s string) (length int, err error) {
+	if len(s) == 0 {
+		return 0, ErrEmptyInput
+	}
+	count := utf8.RuneCountInString(s)
+	r, _ := utf8.DecodeLastRuneInString(s)
+	if first, size := utf8.DecodeRuneInString(s); enc.isByteOrderMark(first) {
+		s, count = s[size:], count-1
+		if count == 0 {
+			return 0, nil
+		}
+	}
+	if first, size := utf8.DecodeRuneInString(s); enc.isMarker(first) {
+		s, count = s[size:], count-1
+		if count == 0 {
+			return 0, nil
+		}
+	}
+	if enc.lengthGlyph {
+		return enc.decodedLenOfLength(s, count)
+	}
+	if !enc.HasPadding() || !enc.isPadding(r) {
+		if !enc.isGlyph(r) && !(enc.isEscape(r) && r >= escapeLow) {
+			return 0, CorruptInputError{count - 1, r, "Invalid character or misplaced padding character"}
+		}
+		// Without padding, the glyphs must end on a full byte.
+		glyphs := count - enc.countEscapes(s)
+		if glyphs%BYTE_LEN*int(enc.bitsPerRune)%BYTE_LEN != 0 {
+			return 0, ErrUnexpectedEnd
+		}
+		return enc.MaxDecodedLen(glyphs), nil
+	}
+	// The padding symbol gives the used bits of the final glyph, which must
+	// complete a byte.
+	// Split into blocks of 8 glyphs like MaxDecodedLen.
+	width, full := int(enc.bitsPerRune), count-2-enc.countEscapes(s)
+	bits := full%BYTE_LEN*width + int(r-enc.paddingStart(r))
+	if full < 0 || bits%BYTE_LEN != 0 {
+		return 0, CorruptInputError{count - 1, r, "Padding character inconsistent with preceding glyphs"}
+	}
+	return full/BYTE_LEN*width + bits/BYTE_LEN, nil
+}
@@ -6,31 +6,96 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/rand"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/grandchild/base32k"
 )
 
+// verbose is set by the -verbose flag.
+var verbose bool
+
 func main() {
 	log.SetFlags(0)
 	decode := flag.Bool("d", false, "Decode the standard input")
 	decodeLong := flag.Bool("decode", false, "Decode the standard input")
+	hex := flag.Bool("hex", false, "Write (or decode with -d) one U+XXXX code point per glyph")
+	count := flag.Bool("count", false, "Print the length of the encoding in glyphs and bytes instead of the encoding")
+	progress := flag.Bool("progress", false, "Encode all of the standard input as a stream, printing the progress to stderr")
+	flag.BoolVar(&verbose, "verbose", false, "Log the mode, the input and output lengths and the position of a decoding error to stderr")
+	selftest := flag.Bool("selftest", false, "")
+	flag.Usage = usage
 	flag.Parse()
 
-	scanner := bufio.NewScanner(os.Stdin)
+	if *selftest {
+		if !runSelftest() {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	writer := bufio.NewWriter(os.Stdout)
-	if !scanner.Scan() {
-		log.Fatal("error reading stdin")
+	if *hex && (*decode || *decodeLong) {
+		logf("mode: decode hex")
+		writer.Write(decodeHex())
+		writer.Write([]byte("\x0a"))
+		writer.Flush()
+		os.Exit(0)
+	}
+
+	if *progress && !(*decode || *decodeLong) {
+		logf("mode: encode stream")
+		encodeStream()
+		os.Exit(0)
 	}
 
 	if *decode || *decodeLong {
-		result, err := base32k.Decode(scanner.Bytes())
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatal("error reading stdin")
+		}
+		// Strip the newline written after the encoding, see below.
+		input = bytes.TrimSuffix(input, []byte("\x0a"))
+		input = bytes.TrimSuffix(input, []byte("\x0d"))
+		logf("mode: decode, input: %d bytes, %d glyphs", len(input), utf8.RuneCount(input))
+		result, err := base32k.Decode(input)
 		if err != nil {
+			logDecodeError(err)
 			log.Fatal(err)
 		}
+		logf("output: %d bytes", len(result))
 		writer.Write(result)
+		writer.Write([]byte("\x0a"))
+		writer.Flush()
+		os.Exit(0)
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		log.Fatal("error reading stdin")
+	}
+	logf("mode: %s, input: %d bytes", encodeMode(*count, *hex), len(scanner.Bytes()))
+	logf("output: %d glyphs", base32k.EncodedLength(len(scanner.Bytes())))
+	if *count {
+		var counter base32k.CountingSink
+		base32k.EncodeToSink(&counter, scanner.Bytes())
+		fmt.Fprintf(writer, "%d glyphs, %d bytes", counter.Runes, counter.Bytes)
+	} else if *hex {
+		for _, r := range base32k.EncodeToRunes(scanner.Bytes()) {
+			fmt.Fprintf(writer, "U+%04X %c\n", r, r)
+		}
+		writer.Flush()
+		os.Exit(0)
 	} else {
 		writer.Write(base32k.Encode(scanner.Bytes()))
 	}
@@ -38,3 +103,159 @@ func main() {
 	writer.Flush()
 	os.Exit(0)
 }
+
+// logf logs to stderr if the -verbose flag is set, stdout only ever receives
+// the results.
+func logf(format string, args ...any) {
+	if verbose {
+		log.Printf(format, args...)
+	}
+}
+
+// logDecodeError logs where decoding failed, see logf.
+func logDecodeError(err error) {
+	var corrupt base32k.CorruptInputError
+	if errors.As(err, &corrupt) {
+		logf("error: glyph %d (%U): %s", corrupt.Position, corrupt.Rune, corrupt.Reason)
+	} else if errors.Is(err, base32k.ErrUnexpectedEnd) {
+		logf("error: end of input")
+	}
+}
+
+// encodeMode names the mode of encoding the flags select, see logf.
+func encodeMode(count, hex bool) string {
+	if count {
+		return "count"
+	} else if hex {
+		return "encode hex"
+	}
+	return "encode"
+}
+
+// progressInterval is the number of bytes after which -progress reports.
+const progressInterval = 1 << 20
+
+// progressReader counts the bytes read from r and reports them to stderr
+// every progressInterval bytes, along with the glyphs written so far.
+type progressReader struct {
+	r      io.Reader
+	read   int
+	glyphs *glyphCounter
+}
+
+func (pr *progressReader) Read(p []byte) (n int, err error) {
+	n, err = pr.r.Read(p)
+	before := pr.read
+	pr.read += n
+	if before/progressInterval != pr.read/progressInterval {
+		pr.report()
+	}
+	return n, err
+}
+
+// report prints the number of bytes read so far and of the glyphs written, on
+// the same line of stderr each time. The encoder holds back the glyphs of an
+// incomplete block and buffers its output, so the glyphs lag behind the bytes
+// until the end.
+func (pr *progressReader) report() {
+	fmt.Fprintf(os.Stderr, "\r%d bytes, %d glyphs", pr.read, pr.glyphs.runes)
+}
+
+// glyphCounter counts the runes written through it to w.
+type glyphCounter struct {
+	w     io.Writer
+	runes int
+}
+
+func (gc *glyphCounter) Write(p []byte) (n int, err error) {
+	n, err = gc.w.Write(p)
+	for _, b := range p[:n] {
+		if b&0xc0 != 0x80 {
+			gc.runes += 1
+		}
+	}
+	return n, err
+}
+
+// encodeStream encodes all of the standard input to the standard output as it
+// is read, with progress reports on stderr.
+func encodeStream() {
+	output := bufio.NewWriter(os.Stdout)
+	glyphs := &glyphCounter{w: output}
+	input := &progressReader{r: os.Stdin, glyphs: glyphs}
+	encoder := base32k.NewEncoder(base32k.StdEncoding, glyphs)
+	if _, err := io.Copy(encoder, input); err != nil {
+		log.Fatal("error reading stdin")
+	}
+	if err := encoder.Close(); err != nil {
+		log.Fatal(err)
+	}
+	output.Write([]byte("\x0a"))
+	output.Flush()
+	input.report()
+	fmt.Fprintln(os.Stderr)
+	logf("input: %d bytes, output: %d glyphs", input.read, glyphs.runes)
+}
+
+// decodeHex decodes a list of code points from the standard input, as written
+// by the -hex flag: one "U+XXXX <glyph>" per line. Only the code points are
+// read, anything else is ignored.
+func decodeHex() (result []byte) {
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatal("error reading stdin")
+	}
+	var runes []rune
+	for _, field := range strings.Fields(string(input)) {
+		codePoint, found := strings.CutPrefix(field, "U+")
+		if !found {
+			continue
+		}
+		r, err := strconv.ParseUint(codePoint, 16, 32)
+		if err != nil {
+			log.Fatalf("invalid code point %q", field)
+		}
+		runes = append(runes, rune(r))
+	}
+	logf("input: %d glyphs", len(runes))
+	result, err = base32k.DecodeFromRunes(runes)
+	if err != nil {
+		logDecodeError(err)
+		log.Fatal(err)
+	}
+	logf("output: %d bytes", len(result))
+	return result
+}
+
+// usage prints the default usage message, leaving out the undocumented
+// -selftest flag.
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+	flag.VisitAll(func(f *flag.Flag) {
+		if f.Name != "selftest" {
+			fmt.Fprintf(flag.CommandLine.Output(), "  -%s\n    \t%s\n", f.Name, f.Usage)
+		}
+	})
+}
+
+// runSelftest round-trips random data of various lengths through encoding and
+// decoding, like TestEncodeDecode does, and prints the results along with a
+// sample for checking that the terminal font renders the glyphs.
+func runSelftest() (ok bool) {
+	ok = true
+	for _, length := range []int{1, 2, 14, 15, 16, 100, 10000, 1000000} {
+		data := make([]byte, length)
+		rand.Read(data)
+		start := time.Now()
+		decoded, err := base32k.Decode(base32k.Encode(data))
+		elapsed := time.Since(start)
+		if err != nil || !bytes.Equal(decoded, data) {
+			fmt.Printf("FAIL  %7d bytes  %v  %v\n", length, elapsed, err)
+			ok = false
+		} else {
+			fmt.Printf("PASS  %7d bytes  %v\n", length, elapsed)
+		}
+	}
+	fmt.Printf("sample: %s\n", base32k.EncodeToString([]byte("testing\n")))
+	return
+}
@@ -0,0 +1,115 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/grandchild/base32k"
+)
+
+// TestMain runs the command line tool instead of the tests when the test
+// binary is started by runTool.
+func TestMain(m *testing.M) {
+	if os.Getenv("BASE32K_RUN_TOOL") == "1" {
+		main()
+	}
+	os.Exit(m.Run())
+}
+
+// runTool runs the command line tool with the given arguments and input, and
+// returns its output.
+func runTool(t *testing.T, input []byte, args ...string) []byte {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "BASE32K_RUN_TOOL=1")
+	cmd.Stdin = bytes.NewReader(input)
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal(fmt.Sprintf("base32k %v failed for %q: %v", args, input, err))
+	}
+	return output
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, input := range []string{"a", "hello", "fifteen bytes!!", "sixteen bytes!!!"} {
+		t.Run(fmt.Sprintf("input_%q", input), func(t *testing.T) {
+			encoded := runTool(t, []byte(input+"\n"))
+			if !bytes.HasSuffix(encoded, []byte("\n")) {
+				t.Error(fmt.Sprintf("Encoding %q doesn't end with a newline", encoded))
+			}
+			for _, flag := range []string{"-d", "-decode"} {
+				if decoded := runTool(t, encoded, flag); string(decoded) != input+"\n" {
+					t.Error(fmt.Sprintf("Decoded %q, expected %q", decoded, input+"\n"))
+				}
+			}
+		})
+	}
+}
+
+func TestProgress(t *testing.T) {
+	input := bytes.Repeat([]byte("streamed\n"), 300000)
+	cmd := exec.Command(os.Args[0], "-progress")
+	cmd.Env = append(os.Environ(), "BASE32K_RUN_TOOL=1")
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	encoded, err := cmd.Output()
+	if err != nil {
+		t.Fatal(fmt.Sprintf("base32k -progress failed: %v", err))
+	}
+	// The whole input is encoded, including its newlines.
+	if decoded := runTool(t, encoded, "-d"); !bytes.Equal(decoded, append(input, '\n')) {
+		t.Error(fmt.Sprintf("Decoded %d bytes, expected %d", len(decoded), len(input)+1))
+	}
+	final := fmt.Sprintf("\r%d bytes, %d glyphs\n", len(input), base32k.EncodedLength(len(input)))
+	if progress := stderr.String(); !strings.HasSuffix(progress, final) || strings.Count(progress, "\r") != 3 {
+		t.Error(fmt.Sprintf("Unexpected progress %q", progress))
+	}
+	// Each report follows the read that crossed the next MiB.
+	for i, line := range strings.Split(strings.TrimSpace(stderr.String()), "\r")[1:] {
+		var read, glyphs int
+		if fmt.Sscanf(line, "%d bytes, %d glyphs", &read, &glyphs); read < (i+1)<<20 || glyphs > base32k.EncodedLength(read) {
+			t.Error(fmt.Sprintf("[%d] Unexpected progress %q", i, line))
+		}
+	}
+}
+
+func TestVerbose(t *testing.T) {
+	run := func(input []byte, args ...string) (stdout, stderr []byte) {
+		cmd := exec.Command(os.Args[0], args...)
+		cmd.Env = append(os.Environ(), "BASE32K_RUN_TOOL=1")
+		cmd.Stdin = bytes.NewReader(input)
+		var errors bytes.Buffer
+		cmd.Stderr = &errors
+		stdout, _ = cmd.Output()
+		return stdout, errors.Bytes()
+	}
+	encoded, logged := run([]byte("hello\n"), "--verbose")
+	if !bytes.Equal(encoded, runTool(t, []byte("hello\n"))) {
+		t.Error(fmt.Sprintf("Verbose output differs: %q", encoded))
+	}
+	if expected := "mode: encode, input: 5 bytes\noutput: 4 glyphs\n"; string(logged) != expected {
+		t.Error(fmt.Sprintf("Logged %q, expected %q", logged, expected))
+	}
+	decoded, logged := run(encoded, "-d", "-verbose")
+	if string(decoded) != "hello\n" || !bytes.Contains(logged, []byte("input: 10 bytes, 4 glyphs")) || !bytes.Contains(logged, []byte("output: 5 bytes")) {
+		t.Error(fmt.Sprintf("Unexpected decoding %q with log %q", decoded, logged))
+	}
+	// A corrupt glyph is logged with its position.
+	corrupt := append(bytes.Clone(encoded[:6]), append([]byte("x"), encoded[6:]...)...)
+	decoded, logged = run(corrupt, "-d", "-verbose")
+	if len(decoded) != 0 || !bytes.Contains(logged, []byte("error: glyph 2 (U+0078)")) {
+		t.Error(fmt.Sprintf("Unexpected decoding %q with log %q", decoded, logged))
+	}
+	// Without -verbose, stderr only gets the error.
+	if _, logged = run(corrupt, "-d"); bytes.Contains(logged, []byte("mode:")) || bytes.Contains(logged, []byte("error: glyph")) {
+		t.Error(fmt.Sprintf("Logged %q without -verbose", logged))
+	}
+}
@@ -6,9 +6,18 @@ package base32k
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/big"
 	"math/rand"
+	"net/url"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"unicode"
+	"unicode/utf8"
 )
 
 // 00000000 11111111 00000000 11111111 10101010 01010101 10101010 01010101 11111111 10100101 01011010 11110000 00001111 10101010 01010101 00000000
@@ -86,6 +95,79 @@ func TestEncodeToString(t *testing.T) {
 			}
 		})
 	}
+	// The string is built separately from Encode, but must be identical.
+	data := make([]byte, 1000)
+	rand.Read(data)
+	for _, enc := range []*Encoding{StdEncoding, SafeEncoding, BMPEncoding, StdEncoding.WithPadding(NoPadding)} {
+		for n := 0; n <= len(data); n += 37 {
+			if encoded := enc.EncodeToString(data[:n]); encoded != string(enc.Encode(data[:n])) {
+				t.Error(fmt.Sprintf("[%d] EncodeToString differs from Encode: %s", n, encoded))
+			}
+		}
+	}
+}
+
+func TestEncodeStringToString(t *testing.T) {
+	for n, expectedString := range encodeExpectedStrings {
+		if encodedString := EncodeStringToString(string(srcData[:n])); encodedString != expectedString {
+			t.Error(fmt.Sprintf("[%d] String '%s' doesn't match expected string '%s'\n", n, encodedString, expectedString))
+		}
+	}
+	data := make([]byte, 1000)
+	rand.Read(data)
+	encodings := []*Encoding{StdEncoding, SafeEncoding, BMPEncoding, StdEncoding.WithPadding(NoPadding),
+		StdEncoding.WithMarker(), StdEncoding.WithLengthGlyph(), StdEncoding.WithExclusions(testExclusions...)}
+	for _, enc := range encodings {
+		for n := 0; n <= len(data); n += 37 {
+			s := string(data[:n])
+			if encoded := enc.EncodeStringToString(s); encoded != enc.EncodeToString([]byte(s)) {
+				t.Error(fmt.Sprintf("[%d] EncodeStringToString differs from EncodeToString: %s", n, encoded))
+			}
+		}
+	}
+	// Only the result is allocated, not a copy of the string.
+	s := string(data)
+	if allocs := testing.AllocsPerRun(10, func() { EncodeStringToString(s) }); allocs > 1 {
+		t.Error(fmt.Sprintf("[%d] EncodeStringToString made %v allocations", len(s), allocs))
+	}
+}
+
+// multiplePatterns fill data of lengths that are multiples of 15 bytes, where
+// the carried bits of the encoder have gone through a full cycle.
+var multiplePatterns = map[string]func(i int) byte{
+	"zeros":       func(int) byte { return 0x00 },
+	"ones":        func(int) byte { return 0xff },
+	"alternating": func(i int) byte { return 0xaa >> (i % 2) },
+	"counting":    func(i int) byte { return byte(i) },
+	"random":      func(int) byte { return byte(rand.Intn(256)) },
+}
+
+func TestEncodeMultiplesOf15(t *testing.T) {
+	for _, n := range []int{0, 15, 30, 45, 120, 150} {
+		for name, pattern := range multiplePatterns {
+			t.Run(fmt.Sprintf("data_size_%d_%s", n, name), func(t *testing.T) {
+				data := make([]byte, n)
+				for i := range data {
+					data[i] = pattern(i)
+				}
+				runes := EncodeToRunes(data)
+				if len(runes) != n/BYTES_PER_RUNE*BYTE_LEN || len(runes) != EncodedLength(n) {
+					t.Error(fmt.Sprintf("[%d] Encoded to %d glyphs, expected %d", n, len(runes), EncodedLength(n)))
+				}
+				for i, r := range runes {
+					if fromLane[r>>12] == 0xfe {
+						t.Error(fmt.Sprintf("[%d] Spurious padding symbol %q at position %d", n, r, i))
+					}
+				}
+				if d := StdEncoding.walkGlyphs(data, func(int, uint16, rune) bool { return true }); d != 0 {
+					t.Error(fmt.Sprintf("[%d] Final glyph reports a padding digit", n))
+				}
+				if decoded, err := DecodeFromRunes(runes); err != nil || !bytes.Equal(decoded, data) {
+					t.Error(fmt.Sprintf("[%d] Round trip failed: %v", n, err))
+				}
+			})
+		}
+	}
 }
 
 func TestDecode(t *testing.T) {
@@ -129,90 +211,844 @@ func TestDecodeFromString(t *testing.T) {
 	}
 }
 
-func TestGetRuneFromBytes(t *testing.T) {
-	data := []byte{0xf0, 0xa5, 0x5a, 0xa5}
-	// 11110000 10100101 01011010 10100101
+func TestDecodeFromStringErrors(t *testing.T) {
+	tests := map[string]struct {
+		src      string
+		position int
+	}{
+		"ascii_in_middle":           {"缀x老j", 1},
+		"padding_in_middle":         {"缀j老j", 1},
+		"padding_digit_too_large":   {"缀老q", 2},
+		"padding_digit_zero":        {"缀老a", 2},
+		"padding_digit_below_range": {"缀老Z", 2},
+		"emoji":                     {"缀老\U0001F600j", 2},
+		"emoji_last":                {"缀老\U0001F600", 2},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodeFromString(test.src)
+			var corrupt CorruptInputError
+			if !errors.As(err, &corrupt) {
+				t.Error(fmt.Sprintf("[%s] Expected CorruptInputError, got: %v", name, err))
+			} else if corrupt.Position != test.position {
+				t.Error(fmt.Sprintf("[%s] Error at position %d, expected %d: %s", name, corrupt.Position, test.position, err))
+			}
+		})
+	}
+}
 
-	// 0010 0101 1111 0000 => 25f0
-	// 0101 0010 1111 1000 => 52f8
-	// 0010 1001 0111 1100 => 297c
-	// 0101 0100 1011 1110 => 54be
-	// 0010 1010 0101 1111 => 2a5f
-	// 0101 0101 0010 1111 => 552f
-	// 0110 1010 1001 0111 => 6a97
-	// 0011 0101 0100 1011 => 354b
+func TestEncodeToRunes(t *testing.T) {
+	for n, expectedString := range encodeExpectedStrings {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			encodedRunes := EncodeToRunes(srcData[:n])
+			if string(encodedRunes) != expectedString {
+				t.Error(fmt.Sprintf("[%d] Runes '%s' don't match expected string '%s'", n, string(encodedRunes), expectedString))
+			}
+		})
+	}
+}
 
-	i := uint(0)
-	expectedIndices := []uint{1, 2, 2, 2, 2, 2, 2, 2}
-	expectedBits := []uint{7, 0, 1, 2, 3, 4, 5, 6}
-	expectedValues := []uint16{0x25f0, 0x52f8, 0x297c, 0x54be, 0x2a5f, 0x552f, 0x6a97, 0x354b}
-	for _, b := range []uint{0, 1, 2, 3, 4, 5, 6, 7} {
-		t.Run(fmt.Sprintf("bit_offset_%d", b), func(t *testing.T) {
-			value, newIndex, newBit, err := getRuneFromBytes(data, i, uint(b))
+func TestDecodeFromRunes(t *testing.T) {
+	for n, decodeSrcString := range encodeExpectedStrings {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			decoded, err := DecodeFromRunes([]rune(decodeSrcString))
 			if err != nil {
-				t.Error(fmt.Sprintf("[b=%d] err raised: %s", b, err))
+				t.Error(fmt.Sprintf("[%d] Error while decoding: %s", n, err))
+			}
+			if !bytes.Equal(decoded, srcData[:n]) {
+				t.Error(fmt.Sprintf("[%d] Expected %x, got %x", n, srcData[:n], decoded))
+			}
+		})
+	}
+	for _, r := range []rune{-1, 0x1f600, utf8.MaxRune + 1} {
+		if _, err := DecodeFromRunes([]rune{0x7f00, r}); err == nil {
+			t.Error(fmt.Sprintf("Expected an error for rune 0x%x", r))
+		}
+	}
+}
+
+func TestEqualEncoded(t *testing.T) {
+	for n, encoded := range encodeExpectedBytes {
+		if !EqualEncoded(encoded, Encode(srcData[:n])) {
+			t.Error(fmt.Sprintf("[%d] Expected encodings to be equal", n))
+		}
+		for m, other := range encodeExpectedBytes {
+			if m != n && EqualEncoded(encoded, other) {
+				t.Error(fmt.Sprintf("[%d] Expected encoding to differ from [%d]", n, m))
+			}
+		}
+	}
+	invalid := []byte("缀x縁")
+	if EqualEncoded(invalid, invalid) {
+		t.Error("Expected invalid encoding not to equal itself")
+	}
+	// Encodings of different lengths are told apart before decoding, which
+	// doesn't allocate.
+	for n, encoded := range encodeExpectedBytes {
+		if length := StdEncoding.impliedLength(encoded); length != n {
+			t.Error(fmt.Sprintf("[%d] Implied length %d", n, length))
+		}
+		if n == 0 {
+			continue
+		}
+		shorter := Encode(srcData[:n-1])
+		if allocs := testing.AllocsPerRun(10, func() { EqualEncoded(encoded, shorter) }); allocs != 0 {
+			t.Error(fmt.Sprintf("[%d] Comparing with %d bytes made %v allocations", n, n-1, allocs))
+		}
+	}
+	marked := StdEncoding.WithMarker().Encode(srcData[:9])
+	if !EqualEncoded(encodeExpectedBytes[9], append([]byte(string(byteOrderMark)), marked...)) {
+		t.Error("Expected encodings with a BOM and marker to be equal")
+	}
+}
+
+func TestGlyphs(t *testing.T) {
+	for n, expectedString := range encodeExpectedStrings {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			expectedRunes := []rune(expectedString)
+			count := 0
+			Glyphs(srcData[:n], func(index int, value uint16, r rune) bool {
+				if index != count*BITS_PER_RUNE {
+					t.Error(fmt.Sprintf("[%d](%d) Expected bit offset %d, got %d", n, count, count*BITS_PER_RUNE, index))
+				}
+				if value > 0x7fff {
+					t.Error(fmt.Sprintf("[%d](%d) Value 0x%x exceeds 15 bits", n, count, value))
+				}
+				if r != expectedRunes[count] {
+					t.Error(fmt.Sprintf("[%d](%d) Expected rune %c, got %c", n, count, expectedRunes[count], r))
+				}
+				count += 1
+				return true
+			})
+			glyphCount := len(expectedRunes)
+			if n%BITS_PER_RUNE != 0 {
+				glyphCount -= 1 // padding symbol
 			}
-			if newIndex != expectedIndices[b] {
-				t.Error(fmt.Sprintf("[b=%d] index incorrect, expected: %d, got: %d", b, expectedIndices[b], newIndex))
+			if count != glyphCount {
+				t.Error(fmt.Sprintf("[%d] Expected %d glyphs, got %d", n, glyphCount, count))
 			}
-			if newBit != expectedBits[b] {
-				t.Error(fmt.Sprintf("[b=%d] bit index incorrect, expected: %d, got: %d", b, expectedBits[b], newBit))
+		})
+	}
+	count := 0
+	Glyphs(srcData, func(int, uint16, rune) bool {
+		count += 1
+		return count < 3
+	})
+	if count != 3 {
+		t.Error(fmt.Sprintf("Expected walk to stop after 3 glyphs, got %d", count))
+	}
+}
+
+func TestEncodeRune(t *testing.T) {
+	for _, enc := range []*Encoding{StdEncoding, SafeEncoding} {
+		seen := map[rune]bool{}
+		for value := uint16(0); value < 1<<enc.bitsPerRune; value += 1 {
+			r := enc.EncodeRune(value)
+			if seen[r] {
+				t.Fatal(fmt.Sprintf("[0x%04x] Glyph %U used twice", value, r))
+			}
+			seen[r] = true
+			if decoded, ok := enc.DecodeRune(r); !ok || decoded != value {
+				t.Fatal(fmt.Sprintf("[0x%04x] Glyph %U decoded to 0x%04x (%v)", value, r, decoded, ok))
+			}
+		}
+	}
+	if r := EncodeRune(0x8000 | 0x1234); r != EncodeRune(0x1234) {
+		t.Error(fmt.Sprintf("Bits above the glyph value not ignored: %U", r))
+	}
+	for _, r := range []rune{0xa000, 0xd000, 0x3fff, 'i', 'a', 0} {
+		if value, ok := DecodeRune(r); ok {
+			t.Error(fmt.Sprintf("[%U] Not a glyph, but decoded to 0x%04x", r, value))
+		}
+	}
+}
+
+// The lane of the values 0b010xxxxxxxxxxxx isn't U+A000 - U+AFFF, which would
+// follow from the lane prefix bits, but U+4000 - U+4FFF (see toLane).
+func TestRemappedLane(t *testing.T) {
+	for value := uint16(0x2000); value < 0x3000; value += 1 {
+		r := EncodeRune(value)
+		if expected := rune(0x4000 | value&0x0fff); r != expected {
+			t.Fatal(fmt.Sprintf("[0x%04x] Expected %U, got %U", value, expected, r))
+		}
+		if decoded, ok := DecodeRune(r); !ok || decoded != value {
+			t.Fatal(fmt.Sprintf("[0x%04x] Glyph %U decoded to 0x%04x (%v)", value, r, decoded, ok))
+		}
+	}
+	// The neighboring lane 0b011 is not remapped.
+	for value := uint16(0x3000); value < 0x4000; value += 1 {
+		if r, expected := EncodeRune(value), rune(0xb000|value&0x0fff); r != expected {
+			t.Fatal(fmt.Sprintf("[0x%04x] Expected %U, got %U", value, expected, r))
+		}
+	}
+	// Both ends of the remapped lane through the whole codec, as the first
+	// glyph of the data.
+	for _, value := range []uint16{0x2000, 0x2fff} {
+		data := []byte{byte(value), byte(value >> 8)}
+		encoded := EncodeToRunes(data)
+		if encoded[0] != rune(0x4000|value&0x0fff) {
+			t.Error(fmt.Sprintf("[0x%04x] Encoded to %U", value, encoded[0]))
+		}
+		if decoded, err := DecodeFromRunes(encoded); err != nil || !bytes.Equal(decoded, data) {
+			t.Error(fmt.Sprintf("[0x%04x] Round trip failed: %x (%v)", value, decoded, err))
+		}
+	}
+	// Keep the fixtures covering the lane.
+	if LaneHistogram(srcData)[0b010] == 0 {
+		t.Error("Fixtures don't cover the remapped lane")
+	}
+}
+
+func TestLaneHistogram(t *testing.T) {
+	for n := range encodeExpectedStrings {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			data := srcData[:n]
+			var expected [8]int
+			for _, r := range EncodeToRunes(data) {
+				if lane := fromLane[r>>12]; lane != 0xfe {
+					expected[lane] += 1
+				}
 			}
-			if value != expectedValues[b] {
-				t.Error(fmt.Sprintf("[b=%d] value incorrect, expected: 0x%0.2x, got: 0x%0.2x", b, expectedValues[b], value))
+			if histogram := LaneHistogram(data); histogram != expected {
+				t.Error(fmt.Sprintf("[%d] Lane histogram should be %v, is %v", n, expected, histogram))
 			}
 		})
 	}
+	// The first glyph has the value 0x2000, in the lane remapped from U+A000.
+	histogram := LaneHistogram([]byte{0x00, 0x20})
+	if histogram != [8]int{1, 0, 1} {
+		t.Error(fmt.Sprintf("Lane histogram of remapped lane is %v", histogram))
+	}
+}
+
+func TestLaneTablesConsistent(t *testing.T) {
+	for _, enc := range []*Encoding{StdEncoding, SafeEncoding} {
+		lanes := 1 << (enc.bitsPerRune - 12)
+		if len(enc.toLane) != lanes || len(enc.fromLane) != 16 {
+			t.Fatal(fmt.Sprintf("[%d] Expected %d lanes and 16 prefixes, got %d and %d", enc.bitsPerRune, lanes, len(enc.toLane), len(enc.fromLane)))
+		}
+		// fromLane is the inverse of toLane on the prefixes in use, e.g. of
+		// the remapped 0x4000 for 0b010, and marks every other prefix.
+		used := 0
+		for prefix, lane := range enc.fromLane {
+			switch {
+			case prefix == 0 && lane != 0xfe:
+				t.Error(fmt.Sprintf("[%d] Prefix 0x0 should mark padding, is 0x%x", enc.bitsPerRune, lane))
+			case prefix != 0 && lane == 0xfe:
+				t.Error(fmt.Sprintf("[%d] Prefix 0x%x marks padding", enc.bitsPerRune, prefix))
+			case lane < 0xfe && (int(lane) >= lanes || int(enc.toLane[lane]>>12) != prefix):
+				t.Error(fmt.Sprintf("[%d] Prefix 0x%x maps to lane %d, which doesn't map back", enc.bitsPerRune, prefix, lane))
+			case lane < 0xfe:
+				used += 1
+			}
+		}
+		for lane, prefix := range enc.toLane {
+			if prefix&0x0fff != 0 {
+				t.Error(fmt.Sprintf("[%d] Lane %d has low bits set: 0x%x", enc.bitsPerRune, lane, prefix))
+			}
+			if enc.fromLane[prefix>>12] != byte(lane) {
+				t.Error(fmt.Sprintf("[%d] Lane %d maps to 0x%x, which doesn't map back", enc.bitsPerRune, lane, prefix))
+			}
+		}
+		if used != lanes {
+			t.Error(fmt.Sprintf("[%d] Expected %d prefixes in use, got %d", enc.bitsPerRune, lanes, used))
+		}
+	}
 }
 
-func TestGetBytesFromRune(t *testing.T) {
-	runes := []rune{0x25f0, 0x52f8, 0x297c, 0x54be, 0x2a5f, 0x552f, 0x6a97, 0x354b}
-	expectedBytes := map[uint]struct {
-		data      []byte
-		remainder byte
-		bit       uint
+func TestDecodeInvalidLane(t *testing.T) {
+	for _, tc := range []struct {
+		enc    *Encoding
+		r      rune
+		reason string
 	}{
-		// 010 0101 1111 0000
-		0: {[]byte{0xf0}, 0x25, 7},
-		// 0100 1011 1110 000.
-		1: {[]byte{0xe0, 0x4b}, 0x00, 0},
-		// 0 1001 0111 1100 00..
-		2: {[]byte{0xc0, 0x97}, 0x00, 1},
-		// 01 0010 1111 1000 0...
-		3: {[]byte{0x80, 0x2f}, 0x01, 2},
-		// 010 0101 1111 0000 ....
-		4: {[]byte{0x00, 0x5f}, 0x02, 3},
-		// 0100 1011 1110 000. ....
-		5: {[]byte{0x00, 0xbe}, 0x04, 4},
-		// 0 1001 0111 1100 00.. ....
-		6: {[]byte{0x00, 0x7c}, 0x09, 5},
-		// 01 0010 1111 1000 0... ....
-		7: {[]byte{0x00, 0xf8}, 0x012, 6},
+		{StdEncoding, 'က', "Character U+1000 in lane 0x1, which the encoding doesn't use"},
+		{StdEncoding, 0x3042, "Character U+3042 in lane 0x3, which the encoding doesn't use"},
+		{StdEncoding, 0xa123, "Character U+A123 in lane 0xa, which the encoding doesn't use"},
+		{StdEncoding, 0xe000, "Character U+E000 in lane 0xe, which the encoding doesn't use"},
+		{StdEncoding, 0xffff, "Character U+FFFF in lane 0xf, which the encoding doesn't use"},
+		{SafeEncoding, 0x9000, "Character U+9000 in lane 0x9, which the encoding doesn't use"},
+		{StdEncoding, 0x1f600, "Invalid character"},
+	} {
+		_, err := tc.enc.DecodeFromRunes([]rune{0x8000, tc.r, 0x8000})
+		var corrupt CorruptInputError
+		if !errors.As(err, &corrupt) || corrupt.Position != 1 || corrupt.Reason != tc.reason {
+			t.Error(fmt.Sprintf("[%U] Expected %q at position 1, got: %v", tc.r, tc.reason, err))
+		}
+	}
+}
+
+func TestDecodeSurrogates(t *testing.T) {
+	for _, surrogate := range []rune{0xd800, 0xdbff, 0xdc00, 0xdfff} {
+		// The three bytes that CESU-8 or WTF-8 encode the surrogate with.
+		encoded := []byte{0xed, byte(0x80 | surrogate>>6&0x3f), byte(0x80 | surrogate&0x3f)}
+		encoded = append(encoded, encodeExpectedBytes[4]...)
+		runes := append([]rune{surrogate}, []rune(encodeExpectedStrings[4])...)
+		for _, enc := range []*Encoding{StdEncoding, SafeEncoding, BMPEncoding} {
+			t.Run(fmt.Sprintf("%d_%U", enc.bitsPerRune, surrogate), func(t *testing.T) {
+				errs := map[string]error{}
+				_, errs["Decode"] = enc.Decode(encoded)
+				_, errs["DecodeFromString"] = enc.DecodeFromString(string(encoded))
+				_, errs["DecodeInto"] = enc.DecodeInto(make([]byte, 16), encoded)
+				_, errs["DecodeFromRunes"] = enc.DecodeFromRunes(runes)
+				_, errs["DecodeFromUTF16"] = enc.DecodeFromUTF16([]uint16{uint16(surrogate)})
+				_, errs["NewDecoder"] = io.ReadAll(NewDecoder(enc, bytes.NewReader(encoded)))
+				errs["ValidEncoding"] = enc.ValidEncoding(encoded)
+				errs["ValidateReader"] = enc.ValidateReader(bytes.NewReader(encoded))
+				_, errs["DecodeRegion"] = enc.DecodeRegion(encoded, 0, 2)
+				_, errs["DecodeFramed"] = enc.DecodeFramed(string(encoded))
+				in := make(chan rune, len(runes))
+				for _, r := range runes {
+					in <- r
+				}
+				close(in)
+				_, errs["DecodeChan"] = enc.DecodeChan(in)
+				for name, err := range errs {
+					var corrupt CorruptInputError
+					if !errors.As(err, &corrupt) || corrupt.Position != 0 || corrupt.Rune != surrogate || corrupt.Reason != "Unpaired surrogate half" {
+						t.Error(fmt.Sprintf("[%s] Expected a surrogate error at 0, got: %v", name, err))
+					}
+				}
+			})
+		}
 	}
-	for b, expected := range expectedBytes {
-		t.Run(fmt.Sprintf("bit_offset_%d", b), func(t *testing.T) {
-			data, remainder, bit := getBytesFromRune(uint16(runes[0]), 0, b)
-			if bit != expected.bit {
-				t.Error(fmt.Sprintf("[%d] bit index incorrect, expected: %d, got: %d", b, expected.bit, bit))
+}
+
+// A rune cut off by the end of the input is the end of the input, not an
+// invalid rune.
+func TestDecodeTruncatedRune(t *testing.T) {
+	glyphs := encodeExpectedBytes[15]
+	framed := []byte(EncodeFramed(srcData[:15]))
+	for cut := 1; cut < 3; cut += 1 {
+		truncated := glyphs[:len(glyphs)-cut]
+		errs := map[string]error{}
+		_, errs["Decode"] = Decode(truncated)
+		_, errs["DecodeFromString"] = DecodeFromString(string(truncated))
+		_, errs["NewDecoder"] = io.ReadAll(NewDecoder(StdEncoding, bytes.NewReader(truncated)))
+		_, errs["DecodeRegion"] = DecodeRegion(truncated, 0, 8)
+		_, errs["DecodeFramed"] = DecodeFramed(string(framed[:len(framed)-cut]))
+		for name, err := range errs {
+			if err != ErrUnexpectedEnd {
+				t.Error(fmt.Sprintf("[%s/%d] Expected ErrUnexpectedEnd, got: %v", name, cut, err))
 			}
-			if remainder != expected.remainder {
-				t.Error(fmt.Sprintf("[%d] remainder incorrect, expected: 0x%0.2x, got: 0x%0.2x", b, expected.remainder, remainder))
+		}
+	}
+}
+
+func TestDecodeTrailingText(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		if n%BITS_PER_RUNE == 0 {
+			continue // no padding character
+		}
+		for _, text := range []string{" is the key", "and more", "\n", "?"} {
+			t.Run(fmt.Sprintf("data_size_%d_%q", n, text), func(t *testing.T) {
+				_, err := DecodeFromString(encoded + text)
+				var corrupt CorruptInputError
+				if !errors.As(err, &corrupt) {
+					t.Error(fmt.Sprintf("[%d] Expected CorruptInputError, got: %v", n, err))
+					return
+				}
+				if expected := utf8.RuneCountInString(encoded); corrupt.Position != expected {
+					t.Error(fmt.Sprintf("[%d] Expected error at position %d, got: %d", n, expected, corrupt.Position))
+				}
+				if !strings.Contains(corrupt.Error(), "after padding") {
+					t.Error(fmt.Sprintf("[%d] Expected error about text after padding, got: %s", n, corrupt))
+				}
+			})
+		}
+	}
+	// A padding character followed by a glyph is reported at the padding.
+	_, err := DecodeFromString("缀老b缀")
+	var corrupt CorruptInputError
+	if !errors.As(err, &corrupt) || corrupt.Position != 2 {
+		t.Error(fmt.Sprintf("Expected misplaced padding error at position 2, got: %v", err))
+	}
+}
+
+func TestTrimTrailingSpace(t *testing.T) {
+	enc := StdEncoding.TrimTrailingSpace()
+	for n, expectedString := range encodeExpectedStrings {
+		for _, space := range []string{"", "\n", "\r\n", " \t\n\n"} {
+			src := expectedString + space
+			results := [][]byte{}
+			for _, decode := range []func() ([]byte, error){
+				func() ([]byte, error) { return enc.Decode([]byte(src)) },
+				func() ([]byte, error) { return enc.DecodeFromString(src) },
+				func() ([]byte, error) { return enc.DecodeFromRunes([]rune(src)) },
+			} {
+				decoded, err := decode()
+				if err != nil {
+					t.Error(fmt.Sprintf("[%d] Error while decoding %q: %s", n, src, err))
+				}
+				results = append(results, decoded)
 			}
-			if len(data) > len(expected.data) {
-				t.Error(fmt.Sprintf("[%d] data too long: %d (need: %d)", b, len(data), len(expected.data)))
+			for _, decoded := range results {
+				if !bytes.Equal(decoded, srcData[:n]) {
+					t.Error(fmt.Sprintf("[%d] Expected %x, got %x", n, srcData[:n], decoded))
+				}
 			}
-			if len(data) < len(expected.data) {
-				t.Error(fmt.Sprintf("[%d] data too short: %d (need: %d)", b, len(data), len(expected.data)))
+			if err := enc.ValidEncoding([]byte(src)); err != nil {
+				t.Error(fmt.Sprintf("[%d] %q not valid: %s", n, src, err))
 			}
-			for j, b := range data {
-				if b != expected.data[j] {
-					t.Error(fmt.Sprintf("[%d](%d) data incorrect, expected: 0x%0.2x, got: 0x%0.2x", b, j, expected.data[j], b))
+		}
+	}
+	if _, err := StdEncoding.DecodeFromString(encodeExpectedStrings[1] + "\n"); err == nil {
+		t.Error("Trailing space accepted without TrimTrailingSpace")
+	}
+	if _, err := enc.DecodeFromString("\n" + encodeExpectedStrings[1]); err == nil {
+		t.Error("Leading space accepted")
+	}
+}
+
+func TestDecodeUnpadded(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		runes := []rune(encoded)
+		if n%BITS_PER_RUNE == 0 {
+			continue // not padded
+		}
+		// Drop the padding character, and then one more glyph each time.
+		for glyphs := len(runes) - 1; glyphs > 0; glyphs -= 1 {
+			t.Run(fmt.Sprintf("data_size_%d_glyphs_%d", n, glyphs), func(t *testing.T) {
+				// Glyphs ending on a byte boundary are indistinguishable from
+				// a valid unpadded encoding.
+				var expectedErr error
+				if glyphs*BITS_PER_RUNE%BYTE_LEN != 0 {
+					expectedErr = ErrUnexpectedEnd
 				}
+				truncated := string(runes[:glyphs])
+				if _, err := DecodeFromString(truncated); err != expectedErr {
+					t.Error(fmt.Sprintf("[%d] Expected error %v, got: %v", n, expectedErr, err))
+				}
+				if _, err := io.ReadAll(NewDecoder(StdEncoding, strings.NewReader(truncated))); err != expectedErr {
+					t.Error(fmt.Sprintf("[%d] Expected error %v from stream decoder, got: %v", n, expectedErr, err))
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeInto(t *testing.T) {
+	for n, encoded := range encodeExpectedBytes {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			// An exact fit must work even if the final glyph yields a byte
+			// that only the padding character removes again.
+			for _, size := range []int{n, n + 1, MaxDecodedLen(utf8.RuneCount(encoded))} {
+				dst := make([]byte, size)
+				written, err := DecodeInto(dst, encoded)
+				if err != nil {
+					t.Error(fmt.Sprintf("[%d] Error decoding into %d bytes: %s", n, size, err))
+				}
+				if written != n || !bytes.Equal(dst[:written], srcData[:n]) {
+					t.Error(fmt.Sprintf("[%d] Expected %x, got %x", n, srcData[:n], dst[:written]))
+				}
+			}
+			if n == 0 {
+				return
+			}
+			written, err := DecodeInto(make([]byte, n-1), encoded)
+			if err != ErrShortBuffer {
+				t.Error(fmt.Sprintf("[%d] Expected ErrShortBuffer, got: %v", n, err))
+			}
+			if written > n-1 {
+				t.Error(fmt.Sprintf("[%d] Wrote %d bytes into %d byte buffer", n, written, n-1))
 			}
 		})
 	}
 }
 
+func TestDecodeInconsistentPadding(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		if n%BITS_PER_RUNE == 0 {
+			continue // no padding character
+		}
+		runes := []rune(encoded)
+		carry := (len(runes) - 1) * BITS_PER_RUNE % BYTE_LEN
+		for digit := 1; digit < BITS_PER_RUNE; digit += 1 {
+			runes[len(runes)-1] = PAD_START_SYMBOL + rune(digit)
+			t.Run(fmt.Sprintf("data_size_%d_digit_%d", n, digit), func(t *testing.T) {
+				decoded, err := DecodeFromRunes(runes)
+				// A smaller digit than the actual one leaves data bits unused.
+				last, _ := StdEncoding.DecodeRune(runes[len(runes)-2])
+				if (BITS_PER_RUNE-digit)%BYTE_LEN != carry || last>>digit != 0 {
+					var corrupt CorruptInputError
+					if !errors.As(err, &corrupt) || corrupt.Position != len(runes)-1 {
+						t.Error(fmt.Sprintf("[%d] Expected CorruptInputError at the padding, got: %v", n, err))
+					}
+				} else if err != nil {
+					t.Error(fmt.Sprintf("[%d] Error while decoding: %s", n, err))
+				} else if len(decoded) < n-1 || len(decoded) > n+1 {
+					t.Error(fmt.Sprintf("[%d] Decoded to unexpected length %d", n, len(decoded)))
+				}
+			})
+		}
+	}
+}
+
+func TestDecodePaddingOnly(t *testing.T) {
+	for _, enc := range []*Encoding{StdEncoding, SafeEncoding, BMPEncoding} {
+		for digit := rune(0); digit < rune(enc.bitsPerRune); digit += 1 {
+			for _, prefix := range []string{"", string(MARKER_SYMBOL)} {
+				encoded := prefix + string(PAD_START_SYMBOL+digit)
+				position := len([]rune(prefix))
+				t.Run(fmt.Sprintf("%d_%q", enc.bitsPerRune, encoded), func(t *testing.T) {
+					var errs []error
+					_, err := enc.Decode([]byte(encoded))
+					errs = append(errs, err)
+					_, err = enc.DecodeFromString(encoded)
+					errs = append(errs, err)
+					_, err = enc.DecodeFromRunes([]rune(encoded))
+					errs = append(errs, err)
+					_, err = enc.DecodeInto(make([]byte, 4), []byte(encoded))
+					errs = append(errs, err)
+					_, err = enc.DecodeInto(nil, []byte(encoded))
+					errs = append(errs, err)
+					errs = append(errs, enc.ValidEncoding([]byte(encoded)))
+					_, err = io.ReadAll(NewDecoder(enc, strings.NewReader(encoded)))
+					errs = append(errs, err)
+					for i, err := range errs {
+						var corrupt CorruptInputError
+						if !errors.As(err, &corrupt) || corrupt.Position != position {
+							t.Error(fmt.Sprintf("[%d] Expected CorruptInputError at %d, got: %v", i, position, err))
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+// PAD_START_SYMBOL itself, the padding digit 0, is never emitted: it is
+// neither read as the end of the glyphs nor as padding that drops a byte.
+func TestDecodePaddingDigitZero(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		glyphs := []rune(encoded)
+		if n%BITS_PER_RUNE != 0 {
+			glyphs = glyphs[:len(glyphs)-1]
+		}
+		if len(glyphs) == 0 {
+			continue
+		}
+		zeroPadded := string(glyphs) + string(PAD_START_SYMBOL)
+		if decoded, err := DecodeFromString(zeroPadded); err != (CorruptInputError{
+			len(glyphs), PAD_START_SYMBOL, "Padding character for a glyph without data bits",
+		}) {
+			t.Error(fmt.Sprintf("[%d] Expected an error for the padding digit 0, got %v (%v)", n, decoded, err))
+		}
+		if _, err := DecodedLenOfString(zeroPadded); !errors.As(err, new(CorruptInputError)) {
+			t.Error(fmt.Sprintf("[%d] Expected DecodedLenOfString to fail for the padding digit 0, got %v", n, err))
+		}
+	}
+	if length := DecodedLength(4, byte(PAD_START_SYMBOL)); length != -1 {
+		t.Error(fmt.Sprintf("Expected DecodedLength -1 for the padding digit 0, got %d", length))
+	}
+	if length := DecodedLength(4, byte(PAD_START_SYMBOL+2)); length < 0 {
+		t.Error(fmt.Sprintf("Expected a DecodedLength for the padding digit 2, got %d", length))
+	}
+}
+
+func TestDecodeByteOrderMark(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		withBOM := string(byteOrderMark) + encoded
+		var results [][]byte
+		var errs []error
+		decoded, err := Decode([]byte(withBOM))
+		results, errs = append(results, decoded), append(errs, err)
+		decoded, err = DecodeFromString(withBOM)
+		results, errs = append(results, decoded), append(errs, err)
+		decoded, err = DecodeFromRunes([]rune(withBOM))
+		results, errs = append(results, decoded), append(errs, err)
+		decoded, err = io.ReadAll(NewDecoder(StdEncoding, strings.NewReader(withBOM)))
+		results, errs = append(results, decoded), append(errs, err)
+		for i := range results {
+			if errs[i] != nil || !bytes.Equal(results[i], srcData[:n]) {
+				t.Error(fmt.Sprintf("[%d/%d] Decoding with a BOM failed: %v (%v)", n, i, results[i], errs[i]))
+			}
+		}
+		if length, err := DecodedLenOfString(withBOM); n > 0 && (err != nil || length != n) {
+			t.Error(fmt.Sprintf("[%d] DecodedLenOfString with a BOM is %d (%v)", n, length, err))
+		}
+	}
+	// The BOM may precede the marker, but is only skipped at the start.
+	marked := StdEncoding.WithMarker()
+	withBOM := string(byteOrderMark) + marked.EncodeToString(srcData[:9])
+	if decoded, err := marked.DecodeFromString(withBOM); err != nil || !bytes.Equal(decoded, srcData[:9]) || !Detect([]byte(withBOM)) {
+		t.Error(fmt.Sprintf("Decoding a marked encoding with a BOM failed: %v (%v)", decoded, err))
+	}
+	encoded := []rune(encodeExpectedStrings[9])
+	inner := string(encoded[:2]) + string(byteOrderMark) + string(encoded[2:])
+	if _, err := DecodeFromString(inner); err != (CorruptInputError{2, byteOrderMark, StdEncoding.invalidReason(byteOrderMark)}) {
+		t.Error(fmt.Sprintf("Expected an error for a BOM within the glyphs, got %v", err))
+	}
+	// U+FEFF is a glyph of BMPEncoding.
+	value, _ := BMPEncoding.DecodeRune(byteOrderMark)
+	data := []byte{byte(value), byte(value >> 8), 1, 2}
+	if encoded := BMPEncoding.EncodeToString(data); !strings.HasPrefix(encoded, string(byteOrderMark)) {
+		t.Error(fmt.Sprintf("Expected BMPEncoding to start with U+FEFF, got %q", encoded))
+	}
+	if decoded, err := BMPEncoding.Decode(BMPEncoding.Encode(data)); err != nil || !bytes.Equal(decoded, data) {
+		t.Error(fmt.Sprintf("BMPEncoding glyph U+FEFF not decoded: %v (%v)", decoded, err))
+	}
+}
+
+func TestDecodeNonCanonical(t *testing.T) {
+	// Every glyph decodes to the value that encodes back to it.
+	for _, enc := range []*Encoding{StdEncoding, SafeEncoding} {
+		for r := rune(0x1000); r < 0x10000; r += 1 {
+			if value, ok := enc.DecodeRune(r); ok && enc.EncodeRune(value) != r {
+				t.Fatal(fmt.Sprintf("[%U] Decodes to 0x%04x, which encodes to %U", r, value, enc.EncodeRune(value)))
+			}
+		}
+	}
+	// The lane U+A000 is remapped to U+4000 and never emitted.
+	for _, r := range []rune{0xa000, 0xa001, 0xaabc, 0xafff} {
+		if _, err := DecodeFromRunes([]rune{r, 0x8000}); !errors.As(err, new(CorruptInputError)) {
+			t.Error(fmt.Sprintf("[%U] Expected CorruptInputError, got: %v", r, err))
+		}
+	}
+	// Unused bits of the final glyph must be zero like the encoder leaves them.
+	for n, encoded := range encodeExpectedStrings {
+		runes := []rune(encoded)
+		if n%BITS_PER_RUNE == 0 {
+			continue // no padding character
+		}
+		digit := runes[len(runes)-1] - PAD_START_SYMBOL
+		for bit := digit; bit < BITS_PER_RUNE; bit += 1 {
+			value, _ := StdEncoding.DecodeRune(runes[len(runes)-2])
+			mangled := append([]rune{}, runes...)
+			mangled[len(runes)-2] = StdEncoding.EncodeRune(value | 1<<bit)
+			_, err := DecodeFromRunes(mangled)
+			var corrupt CorruptInputError
+			if !errors.As(err, &corrupt) || corrupt.Position != len(runes)-1 {
+				t.Error(fmt.Sprintf("[%d] Expected CorruptInputError for unused bit %d, got: %v", n, bit, err))
+			}
+			if err := ValidEncoding([]byte(string(mangled))); err == nil {
+				t.Error(fmt.Sprintf("[%d] Unused bit %d accepted as valid", n, bit))
+			}
+		}
+	}
+}
+
+func TestDecodeInvalidUTF8(t *testing.T) {
+	// "缀" (U+7F00) followed by a malformed sequence. The overlong forms would
+	// otherwise decode to a data glyph (U+4E00) or the padding symbol 'i'.
+	prefix := []byte{0xe7, 0xbc, 0x80}
+	sequences := map[string][]byte{
+		"overlong_cjk_4_bytes":   {0xf0, 0x84, 0xb8, 0x80},
+		"overlong_padding_2":     {0xc1, 0xa9},
+		"overlong_padding_3":     {0xe0, 0x81, 0xa9},
+		"truncated_inner_glyph":  {0xe4, 0xb8, 0xe7, 0xbc, 0x80},
+		"stray_continuation":     {0x80},
+		"encoded_surrogate":      {0xed, 0xa0, 0x80},
+		"overlong_nul_2_bytes":   {0xc0, 0x80},
+		"overlong_hangul_4_byte": {0xf0, 0x8b, 0x80, 0x80},
+	}
+	for name, sequence := range sequences {
+		t.Run(name, func(t *testing.T) {
+			_, err := Decode(append(append([]byte{}, prefix...), sequence...))
+			var corrupt CorruptInputError
+			if !errors.As(err, &corrupt) {
+				t.Error(fmt.Sprintf("[%s] Expected CorruptInputError, got: %v", name, err))
+				return
+			}
+			if corrupt.Position != 1 {
+				t.Error(fmt.Sprintf("[%s] Expected error at position 1, got: %d", name, corrupt.Position))
+			}
+		})
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	encoded := encodeExpectedStrings[16]
+	// Truncated input, which more input may complete, and invalid input.
+	truncated := map[string]string{
+		"padding_missing": encoded[:len(encoded)-1],
+		"glyph_cut_1":     encoded[:len(encoded)-2],
+		"glyph_cut_2":     encoded[:len(encoded)-3],
+	}
+	invalid := map[string]string{
+		"invalid_rune":      encoded[:len(encoded)-1] + "!",
+		"stray_byte":        encoded[:len(encoded)-1] + "\xff",
+		"invalid_then_more": encoded[:len(encoded)-3] + "\xe4\xb8" + encoded[len(encoded)-1:],
+	}
+	decoders := map[string]func(string) error{
+		"bytes":  func(s string) error { _, err := Decode([]byte(s)); return err },
+		"string": func(s string) error { _, err := DecodeFromString(s); return err },
+		"stream": func(s string) error { _, err := io.ReadAll(NewDecoder(StdEncoding, strings.NewReader(s))); return err },
+		"stream_unbuffered": func(s string) error {
+			_, err := io.ReadAll(NewDecoder(StdEncoding, iotest.OneByteReader(strings.NewReader(s))))
+			return err
+		},
+	}
+	for decoderName, decode := range decoders {
+		for name, src := range truncated {
+			if err := decode(src); err != ErrUnexpectedEnd || !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Error(fmt.Sprintf("[%s/%s] Expected ErrUnexpectedEnd, got: %v", decoderName, name, err))
+			}
+		}
+		for name, src := range invalid {
+			var corrupt CorruptInputError
+			if err := decode(src); !errors.As(err, &corrupt) || errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Error(fmt.Sprintf("[%s/%s] Expected CorruptInputError, got: %v", decoderName, name, err))
+			}
+		}
+	}
+}
+
+func TestEncodedLengthOverflow(t *testing.T) {
+	// Lengths whose bit-length overflows a 32-bit int, plus the largest ints of
+	// the platform. Run with GOARCH=386 to check an actual 32-bit int.
+	lengths := []int{math.MaxInt32/BYTE_LEN + 1, math.MaxInt32 - 1, math.MaxInt32, math.MaxInt - 1, math.MaxInt}
+	for _, n := range lengths {
+		t.Run(fmt.Sprintf("length_%d", n), func(t *testing.T) {
+			bits := new(big.Int).Mul(big.NewInt(int64(n)), big.NewInt(BYTE_LEN))
+			bits.Add(bits, big.NewInt(BITS_PER_RUNE-1))
+			expected := bits.Div(bits, big.NewInt(BITS_PER_RUNE)).Int64()
+			if n%BITS_PER_RUNE != 0 {
+				expected += 1
+			}
+			if length := EncodedLength(n); int64(length) != expected {
+				t.Error(fmt.Sprintf("[%d] Expected length %d, got %d", n, expected, length))
+			}
+		})
+	}
+}
+
+func TestEncodedByteLength(t *testing.T) {
+	for n, expected := range encodeExpectedBytes {
+		if length := EncodedByteLength(n); length != len(expected) {
+			t.Error(fmt.Sprintf("[%d] Encoded byte length should be %d, is %d", n, len(expected), length))
+		}
+	}
+	unpadded := StdEncoding.WithPadding(NoPadding)
+	for n := 0; n <= 100; n += 1 {
+		data := make([]byte, n)
+		rand.Read(data)
+		for _, enc := range []*Encoding{StdEncoding, SafeEncoding, unpadded} {
+			if length, expected := enc.EncodedByteLength(n), len(enc.Encode(data)); length != expected {
+				t.Error(fmt.Sprintf("[%d] Encoded byte length should be %d, is %d", n, expected, length))
+			}
+		}
+	}
+}
+
+func TestDecodedLenOfString(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		if n == 0 {
+			continue
+		}
+		if length, err := DecodedLenOfString(encoded); err != nil || length != n {
+			t.Error(fmt.Sprintf("[%d] Decoded length should be %d, is %d (%v)", n, n, length, err))
+		}
+	}
+	encodings := []*Encoding{
+		StdEncoding, SafeEncoding, BMPEncoding, StdEncoding.WithPadding(NoPadding),
+		StdEncoding.WithPadding('A'), StdEncoding.WithExclusions(testExclusions...),
+	}
+	data := append(excludedData(), make([]byte, 85)...)
+	rand.Read(data[15:])
+	for n := 1; n <= len(data); n += 1 {
+		for _, enc := range encodings {
+			encoded := enc.EncodeToString(data[:n])
+			// Unpadded encodings of most lengths don't decode.
+			decoded, decodeErr := enc.DecodeFromString(encoded)
+			length, err := enc.DecodedLenOfString(encoded)
+			if err != decodeErr || length != len(decoded) {
+				t.Error(fmt.Sprintf("[%d] Decoded length of %v should be %d, is %d (%v)", n, enc, len(decoded), length, err))
+			}
+		}
+	}
+	for _, tc := range []struct {
+		src      string
+		position int
+	}{{"缀老!", 2}, {"缀老i", 2}, {"j", 0}} {
+		if _, err := DecodedLenOfString(tc.src); err == nil || err.(CorruptInputError).Position != tc.position {
+			t.Error(fmt.Sprintf("[%s] Expected an error at %d, got %v", tc.src, tc.position, err))
+		}
+	}
+	if _, err := DecodedLenOfString("缀老"); err != ErrUnexpectedEnd {
+		t.Error("Expected ErrUnexpectedEnd, got", err)
+	}
+	if _, err := DecodedLenOfString(""); err != ErrEmptyInput {
+		t.Error("Expected ErrEmptyInput, got", err)
+	}
+}
+
+func TestDecodeHint(t *testing.T) {
+	hint := func(enc *Encoding, s string) int {
+		last, _ := utf8.DecodeLastRuneInString(s)
+		return enc.decodeHint(utf8.RuneCountInString(s), last)
+	}
+	for n, encoded := range encodeExpectedStrings {
+		if h := hint(StdEncoding, encoded); h != n {
+			t.Error(fmt.Sprintf("[%d] Expected a hint of %d bytes, got %d", n, n, h))
+		}
+	}
+	for _, enc := range []*Encoding{SafeEncoding, BMPEncoding, StdEncoding.WithPadding(NoPadding)} {
+		for n := 1; n < 50; n += 1 {
+			encoded := enc.EncodeToString(srcData[:n%len(srcData)+1])
+			decoded, err := enc.DecodeFromString(encoded)
+			if h := hint(enc, encoded); err == nil && h != len(decoded) {
+				t.Error(fmt.Sprintf("[%d/%d] Expected a hint of %d bytes, got %d", enc.bitsPerRune, n, len(decoded), h))
+			}
+		}
+	}
+}
+
+func TestEncodeStats(t *testing.T) {
+	unpadded := StdEncoding.WithPadding(NoPadding)
+	for _, n := range []int{0, 1, 2, 14, 15, 16, 262, 263, 264, 1000} {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			data := make([]byte, n)
+			rand.Read(data)
+			for _, enc := range []*Encoding{StdEncoding, SafeEncoding, BMPEncoding, unpadded} {
+				encoded := enc.Encode(data)
+				glyphs, utf8Bytes, tweets := enc.EncodeStats(data)
+				if count := utf8.RuneCount(encoded); glyphs != count {
+					t.Error(fmt.Sprintf("[%d] Glyph count should be %d, is %d", n, count, glyphs))
+				}
+				if utf8Bytes != len(encoded) {
+					t.Error(fmt.Sprintf("[%d] Byte count should be %d, is %d", n, len(encoded), utf8Bytes))
+				}
+				if tweets*GLYPHS_PER_TWEET < glyphs || (tweets > 0 && (tweets-1)*GLYPHS_PER_TWEET >= glyphs) {
+					t.Error(fmt.Sprintf("[%d] %d glyphs don't need %d tweets", n, glyphs, tweets))
+				}
+			}
+		})
+	}
+}
+
+func TestPercentEncodedLength(t *testing.T) {
+	unpadded := StdEncoding.WithPadding(NoPadding)
+	for n := 0; n <= 100; n += 1 {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			data := make([]byte, n)
+			rand.Read(data)
+			for _, enc := range []*Encoding{StdEncoding, unpadded} {
+				expected := len(url.QueryEscape(enc.EncodeToString(data)))
+				if length := enc.PercentEncodedLength(n); length != expected {
+					t.Error(fmt.Sprintf("[%d] Percent-encoded length should be %d, is %d", n, expected, length))
+				}
+			}
+		})
+	}
+}
+
+func TestMaxDecodedLen(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		glyphCount := utf8.RuneCountInString(encoded)
+		if n%BITS_PER_RUNE != 0 {
+			glyphCount -= 1 // padding symbol
+		}
+		maxLength := MaxDecodedLen(glyphCount)
+		if maxLength < n || maxLength > n+1 {
+			t.Error(fmt.Sprintf("[%d] Expected maximum length of %d or %d, got %d", n, n, n+1, maxLength))
+		}
+	}
+	if length := MaxDecodedLen(math.MaxInt / 2); length < 0 {
+		t.Error(fmt.Sprintf("Maximum length overflowed: %d", length))
+	}
+}
+
 func TestEncodeDecode(t *testing.T) {
 	lengths := []int{100, 10000, 1000000, 100000000}
 	for _, length := range lengths {
@@ -244,3 +1080,445 @@ func TestEncodeDecode(t *testing.T) {
 		})
 	}
 }
+
+// The length functions must agree with the actual encoding and decoding of
+// random data of random lengths.
+func TestLengthInvariants(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	data := make([]byte, 1000)
+	for _, enc := range []*Encoding{StdEncoding, SafeEncoding, BMPEncoding} {
+		for i := 0; i < 10000; i += 1 {
+			src := data[:rng.Intn(len(data)+1)]
+			rng.Read(src)
+			encoded := enc.Encode(src)
+			if count := utf8.RuneCount(encoded); count != enc.EncodedLength(len(src)) {
+				t.Fatal(fmt.Sprintf("[%d] Encoded to %d runes, EncodedLength is %d", len(src), count, enc.EncodedLength(len(src))))
+			}
+			// For BMPEncoding, whose glyphs vary in length, it's the maximum.
+			if len(encoded) != enc.EncodedByteLength(len(src)) && (!enc.bmp || len(encoded) > enc.EncodedByteLength(len(src))) {
+				t.Fatal(fmt.Sprintf("[%d] Encoded to %d bytes, EncodedByteLength is %d", len(src), len(encoded), enc.EncodedByteLength(len(src))))
+			}
+			decoded, err := enc.Decode(encoded)
+			if err != nil || len(decoded) != len(src) {
+				t.Fatal(fmt.Sprintf("[%d] Decoded to %d bytes (%v)", len(src), len(decoded), err))
+			}
+			if length, err := enc.DecodedLenOfString(string(encoded)); len(src) > 0 && (err != nil || length != len(src)) {
+				t.Fatal(fmt.Sprintf("[%d] DecodedLenOfString is %d (%v)", len(src), length, err))
+			}
+		}
+	}
+}
+
+func TestSafeEncoding(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for length := 0; length < 300; length += 1 {
+		t.Run(fmt.Sprintf("length_%d", length), func(t *testing.T) {
+			expectedData := make([]byte, length)
+			rng.Read(expectedData)
+			encoded := SafeEncoding.EncodeToRunes(expectedData)
+			if len(encoded) != SafeEncoding.EncodedLength(length) {
+				t.Error(fmt.Sprintf("[%d] Expected %d runes, got %d", length, SafeEncoding.EncodedLength(length), len(encoded)))
+			}
+			for i, r := range encoded {
+				if i == len(encoded)-1 && r > PAD_START_SYMBOL && r < PAD_START_SYMBOL+14 {
+					continue
+				}
+				if r < 0x5000 || r > 0x8fff {
+					t.Error(fmt.Sprintf("[%d](%d) Rune U+%04X outside of the safe subset", length, i, r))
+				}
+			}
+			data, err := SafeEncoding.DecodeFromString(string(encoded))
+			if err != nil {
+				t.Error("Error while decoding:", err)
+			}
+			if !bytes.Equal(data, expectedData) {
+				t.Error(fmt.Sprintf("[%d] Expected %x, got %x", length, expectedData, data))
+			}
+		})
+	}
+	// Glyphs outside the safe subset are rejected.
+	if _, err := SafeEncoding.DecodeFromString(encodeExpectedStrings[16]); err == nil {
+		t.Error("Expected an error when decoding a StdEncoding string")
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	encoded := Encode(data)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 1 {
+		Decode(encoded)
+	}
+}
+
+func BenchmarkDecodeFromString(b *testing.B) {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	encoded := EncodeToString(data)
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i += 1 {
+			DecodeFromString(encoded)
+		}
+	})
+	b.Run("bytes_conversion", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i += 1 {
+			Decode([]byte(encoded))
+		}
+	})
+}
+
+func BenchmarkEncodeToString(b *testing.B) {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	b.Run("builder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i += 1 {
+			EncodeToString(data)
+		}
+	})
+	b.Run("bytes_conversion", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i += 1 {
+			_ = string(Encode(data))
+		}
+	})
+}
+
+func TestBMPEncoding(t *testing.T) {
+	seen := map[rune]bool{}
+	for value := 0; value < 1<<16; value += 1 {
+		r := BMPEncoding.EncodeRune(uint16(value))
+		if !utf8.ValidRune(r) || r < bmpOffset || seen[r] {
+			t.Fatal(fmt.Sprintf("[0x%04x] Invalid or reused glyph %U", value, r))
+		}
+		seen[r] = true
+		if decoded, ok := BMPEncoding.DecodeRune(r); !ok || int(decoded) != value {
+			t.Fatal(fmt.Sprintf("[0x%04x] Glyph %U decoded to 0x%04x (%v)", value, r, decoded, ok))
+		}
+	}
+	for _, r := range []rune{'i', 0xff, 0xd800, 0xdfff, 0x10900, -1} {
+		if value, ok := BMPEncoding.DecodeRune(r); ok {
+			t.Error(fmt.Sprintf("[%U] Not a glyph, but decoded to 0x%04x", r, value))
+		}
+	}
+	// U+FFFD is a glyph, not an invalid UTF-8 sequence.
+	value, _ := BMPEncoding.DecodeRune(utf8.RuneError)
+	written, _ := (&bitWriter{width: 16}).write(value)
+	replacement := written[:]
+	encoded := BMPEncoding.EncodeToString(replacement)
+	if encoded != "\ufffd" {
+		t.Error(fmt.Sprintf("Expected U+FFFD, got %q", encoded))
+	}
+	if decoded, err := BMPEncoding.DecodeFromString(encoded); err != nil || !bytes.Equal(decoded, replacement) {
+		t.Error(fmt.Sprintf("U+FFFD round trip failed: %x (%v)", decoded, err))
+	}
+	if decoded, err := BMPEncoding.Decode([]byte(encoded)); err != nil || !bytes.Equal(decoded, replacement) {
+		t.Error(fmt.Sprintf("U+FFFD round trip failed: %x (%v)", decoded, err))
+	}
+	for n := 0; n <= 100; n += 1 {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			data := make([]byte, n)
+			rand.Read(data)
+			encoded := BMPEncoding.EncodeToString(data)
+			if glyphs := (n + 1) / 2; utf8.RuneCountInString(encoded) != glyphs+n%2 {
+				t.Error(fmt.Sprintf("[%d] Encoded to %d runes, expected %d", n, utf8.RuneCountInString(encoded), glyphs+n%2))
+			}
+			decoded, err := BMPEncoding.DecodeFromString(encoded)
+			if err != nil || !bytes.Equal(decoded, data) {
+				t.Error(fmt.Sprintf("[%d] Round trip failed: %x (%v)", n, decoded, err))
+			}
+			streamed, err := io.ReadAll(NewDecoder(BMPEncoding, strings.NewReader(encoded)))
+			if err != nil || !bytes.Equal(streamed, data) {
+				t.Error(fmt.Sprintf("[%d] Stream round trip failed: %x (%v)", n, streamed, err))
+			}
+		})
+	}
+	if _, err := BMPEncoding.DecodeFromString(BMPEncoding.EncodeToString(srcData) + "x"); err == nil {
+		t.Error("Expected an error for trailing text")
+	}
+}
+
+func TestWithPadding(t *testing.T) {
+	encodings := map[string]*Encoding{
+		"uppercase": StdEncoding.WithPadding('A'),
+		"digits":    StdEncoding.WithPadding('0'),
+		"none":      StdEncoding.WithPadding(NoPadding),
+	}
+	for name, enc := range encodings {
+		for n := range encodeExpectedStrings {
+			encoded := enc.EncodeToString(srcData[:n])
+			expected := []rune(encodeExpectedStrings[n])
+			glyphs := []rune(encoded)
+			if n%BITS_PER_RUNE != 0 {
+				if enc.padStart == NoPadding {
+					expected = expected[:len(expected)-1]
+				} else {
+					expected[len(expected)-1] += enc.padStart - PAD_START_SYMBOL
+				}
+			}
+			if string(glyphs) != string(expected) {
+				t.Error(fmt.Sprintf("[%s/%d] Expected '%s', got '%s'", name, n, string(expected), encoded))
+			}
+			decoded, err := enc.DecodeFromString(encoded)
+			if enc.padStart == NoPadding && len(glyphs)*BITS_PER_RUNE%BYTE_LEN != 0 {
+				if err != ErrUnexpectedEnd {
+					t.Error(fmt.Sprintf("[%s/%d] Expected ErrUnexpectedEnd, got: %v", name, n, err))
+				}
+			} else if err != nil || !bytes.Equal(decoded, srcData[:n]) {
+				t.Error(fmt.Sprintf("[%s/%d] Expected %x, got %x (%v)", name, n, srcData[:n], decoded, err))
+			}
+		}
+	}
+	if _, err := StdEncoding.WithPadding(NoPadding).DecodeFromString(encodeExpectedStrings[16]); err == nil {
+		t.Error("Expected an error for a padding character without padding")
+	}
+}
+
+func TestHasPadding(t *testing.T) {
+	unpadded := StdEncoding.WithPadding(NoPadding)
+	if !StdEncoding.HasPadding() || !BMPEncoding.HasPadding() || !StdEncoding.WithPadding('A').HasPadding() {
+		t.Error("Padded encoding reports no padding")
+	}
+	if unpadded.HasPadding() || SafeEncoding.WithPadding(NoPadding).HasPadding() {
+		t.Error("Unpadded encoding reports padding")
+	}
+	data := make([]byte, 100)
+	rand.Read(data)
+	for n := 1; n <= len(data); n += 1 {
+		encoded := unpadded.EncodeToString(data[:n])
+		glyphs := utf8.RuneCountInString(encoded)
+		if glyphs != unpadded.EncodedLength(n) {
+			t.Error(fmt.Sprintf("[%d] Encoded to %d glyphs, expected %d", n, glyphs, unpadded.EncodedLength(n)))
+		}
+		decoded, err := unpadded.DecodeFromString(encoded)
+		length, lengthErr := unpadded.DecodedLenOfString(encoded)
+		if glyphs*BITS_PER_RUNE%BYTE_LEN == 0 {
+			// Byte-aligned glyphs decode to all of their bits, which may
+			// include a byte of zero bits beyond the data.
+			expected := append(data[:n:n], make([]byte, glyphs*BITS_PER_RUNE/BYTE_LEN-n)...)
+			if err != nil || !bytes.Equal(decoded, expected) {
+				t.Error(fmt.Sprintf("[%d] Expected %x, got %x (%v)", n, expected, decoded, err))
+			}
+			if lengthErr != nil || length != len(expected) {
+				t.Error(fmt.Sprintf("[%d] Decoded length should be %d, is %d (%v)", n, len(expected), length, lengthErr))
+			}
+		} else if err != ErrUnexpectedEnd || lengthErr != ErrUnexpectedEnd {
+			t.Error(fmt.Sprintf("[%d] Expected ErrUnexpectedEnd, got: %v, %v", n, err, lengthErr))
+		}
+	}
+	if _, err := unpadded.DecodedLenOfString(encodeExpectedStrings[1]); err == nil {
+		t.Error("Expected an error for a padding character without padding")
+	}
+}
+
+func TestLegacyPadding(t *testing.T) {
+	for _, padding := range []rune{'A', '0', 0x0800} {
+		enc := StdEncoding.WithPadding(padding)
+		strict := enc.StrictPadding()
+		for n, encoded := range encodeExpectedStrings {
+			t.Run(fmt.Sprintf("padding_%U_data_size_%d", padding, n), func(t *testing.T) {
+				decoded, err := enc.DecodeFromString(encoded)
+				if err != nil || !bytes.Equal(decoded, srcData[:n]) {
+					t.Error(fmt.Sprintf("[%d] Legacy padding: expected %x, got %x (%v)", n, srcData[:n], decoded, err))
+				}
+				if decoded, err = io.ReadAll(NewDecoder(enc, strings.NewReader(encoded), StopAtInvalid)); err != nil || !bytes.Equal(decoded, srcData[:n]) {
+					t.Error(fmt.Sprintf("[%d] Legacy padding from stream decoder: expected %x, got %x (%v)", n, srcData[:n], decoded, err))
+				}
+				_, err = strict.DecodeFromString(encoded)
+				if n%BITS_PER_RUNE != 0 && err == nil {
+					t.Error(fmt.Sprintf("[%d] Legacy padding accepted with strict padding", n))
+				} else if n%BITS_PER_RUNE == 0 && err != nil {
+					t.Error(fmt.Sprintf("[%d] Error while decoding unpadded input: %s", n, err))
+				}
+			})
+		}
+	}
+	// The configured padding wins where the ranges overlap: 'i' is the digit 5
+	// of the padding starting at 'd', not the legacy digit 8.
+	overlapping := StdEncoding.WithPadding('d')
+	if decoded, err := overlapping.DecodeFromString("耀l"); err != nil || !bytes.Equal(decoded, srcData[:1]) {
+		t.Error(fmt.Sprintf("Overlapping padding: expected %x, got %x (%v)", srcData[:1], decoded, err))
+	}
+	if _, err := overlapping.DecodeFromString(encodeExpectedStrings[1]); err == nil {
+		t.Error("Overlapping padding read as legacy padding")
+	}
+	// Without padding, legacy padding isn't accepted either.
+	if _, err := StdEncoding.WithPadding(NoPadding).DecodeFromString(encodeExpectedStrings[1]); err == nil {
+		t.Error("Legacy padding accepted without padding")
+	}
+}
+
+func TestClone(t *testing.T) {
+	clone := StdEncoding.Clone()
+	clone.fromLane[0x4] = 0xff
+	clone.toLane[0x2] = 0xa000
+	if fromLane[0x4] != 2 || toLane[0x2] != 0x4000 {
+		t.Error("Modifying the cloned lane tables modified the original ones")
+	}
+	unpadded := StdEncoding.Clone().WithPadding(NoPadding)
+	if unpadded.padStart != NoPadding || StdEncoding.padStart != PAD_START_SYMBOL {
+		t.Error("Changing the padding of the clone changed the original")
+	}
+	if EncodeToString(srcData) != encodeExpectedStrings[16] {
+		t.Error("StdEncoding output changed after modifying clones")
+	}
+}
+
+func TestEmptyInput(t *testing.T) {
+	// The empty-input contract of the default encoding.
+	if encoded := Encode([]byte{}); encoded != nil {
+		t.Error(fmt.Sprintf("Empty input should encode to nil, is %v", encoded))
+	}
+	if encoded := EncodeToString(nil); encoded != "" {
+		t.Error(fmt.Sprintf("Empty input should encode to \"\", is %q", encoded))
+	}
+	if encoded := EncodeToRunes([]byte{}); encoded != nil {
+		t.Error(fmt.Sprintf("Empty input should encode to nil, is %v", encoded))
+	}
+	if decoded, err := Decode([]byte{}); decoded != nil || err != nil {
+		t.Error(fmt.Sprintf("Empty input should decode to nil, is %v, %v", decoded, err))
+	}
+	if decoded, err := DecodeFromString(""); decoded != nil || err != nil {
+		t.Error(fmt.Sprintf("Empty input should decode to nil, is %v, %v", decoded, err))
+	}
+	if decoded, err := DecodeFromRunes(nil); decoded != nil || err != nil {
+		t.Error(fmt.Sprintf("Empty input should decode to nil, is %v, %v", decoded, err))
+	}
+	if n, err := DecodeInto(make([]byte, 1), nil); n != 0 || err != nil {
+		t.Error(fmt.Sprintf("Empty input should decode to nothing, is %d, %v", n, err))
+	}
+
+	strict := StdEncoding.RequireNonEmpty()
+	if _, err := strict.EncodeChecked([]byte{}); err != ErrEmptyInput {
+		t.Error(fmt.Sprintf("Expected ErrEmptyInput when encoding, got: %v", err))
+	}
+	if _, err := strict.Decode(nil); err != ErrEmptyInput {
+		t.Error(fmt.Sprintf("Expected ErrEmptyInput from Decode, got: %v", err))
+	}
+	if _, err := strict.DecodeFromString(""); err != ErrEmptyInput {
+		t.Error(fmt.Sprintf("Expected ErrEmptyInput from DecodeFromString, got: %v", err))
+	}
+	if _, err := strict.DecodeFromRunes([]rune{}); err != ErrEmptyInput {
+		t.Error(fmt.Sprintf("Expected ErrEmptyInput from DecodeFromRunes, got: %v", err))
+	}
+	if _, err := strict.DecodeInto(nil, nil); err != ErrEmptyInput {
+		t.Error(fmt.Sprintf("Expected ErrEmptyInput from DecodeInto, got: %v", err))
+	}
+	if _, err := io.ReadAll(NewDecoder(strict, strings.NewReader(""))); err != ErrEmptyInput {
+		t.Error(fmt.Sprintf("Expected ErrEmptyInput from the stream decoder, got: %v", err))
+	}
+	if _, err := StdEncoding.EncodeChecked(nil); err != nil {
+		t.Error(fmt.Sprintf("Default encoding rejected empty input: %v", err))
+	}
+	for n, expected := range encodeExpectedStrings {
+		if n == 0 {
+			continue
+		}
+		encoded, err := strict.EncodeChecked(srcData[:n])
+		if err != nil || string(encoded) != expected {
+			t.Error(fmt.Sprintf("[%d] Strict encoding mismatch: %q, %v", n, encoded, err))
+		}
+		if decoded, err := strict.DecodeFromString(expected); err != nil || !bytes.Equal(decoded, srcData[:n]) {
+			t.Error(fmt.Sprintf("[%d] Strict decoding mismatch: %v, %v", n, decoded, err))
+		}
+	}
+}
+
+func TestMaxInputBytes(t *testing.T) {
+	dataLimited := StdEncoding.WithMaxInputBytes(len(srcData))
+	if _, err := dataLimited.EncodeChecked(srcData); err != nil {
+		t.Error(fmt.Sprintf("Input at the limit rejected when encoding: %v", err))
+	}
+	if _, err := dataLimited.EncodeChecked(append(srcData, 0)); err != ErrInputTooLarge {
+		t.Error(fmt.Sprintf("Expected ErrInputTooLarge when encoding, got: %v", err))
+	}
+	// Decoding, at the limit and one byte (or rune) above it.
+	encoded := encodeExpectedBytes[16]
+	limited := StdEncoding.WithMaxInputBytes(len(encoded))
+	if decoded, err := limited.Decode(encoded); err != nil || !bytes.Equal(decoded, srcData) {
+		t.Error(fmt.Sprintf("Input at the limit rejected by Decode: %v", err))
+	}
+	if _, err := limited.DecodeFromString(string(encoded)); err != nil {
+		t.Error(fmt.Sprintf("Input at the limit rejected by DecodeFromString: %v", err))
+	}
+	if err := limited.ValidEncoding(encoded); err != nil {
+		t.Error(fmt.Sprintf("Input at the limit rejected by ValidEncoding: %v", err))
+	}
+	tooLarge := append([]byte("x"), encoded...)
+	if _, err := limited.Decode(tooLarge); err != ErrInputTooLarge {
+		t.Error(fmt.Sprintf("Expected ErrInputTooLarge from Decode, got: %v", err))
+	}
+	if _, err := limited.DecodeFromString(string(tooLarge)); err != ErrInputTooLarge {
+		t.Error(fmt.Sprintf("Expected ErrInputTooLarge from DecodeFromString, got: %v", err))
+	}
+	if err := limited.ValidEncoding(tooLarge); err != ErrInputTooLarge {
+		t.Error(fmt.Sprintf("Expected ErrInputTooLarge from ValidEncoding, got: %v", err))
+	}
+	runes := []rune(string(encoded))
+	runeLimited := StdEncoding.WithMaxInputBytes(len(runes))
+	if _, err := runeLimited.DecodeFromRunes(runes); err != nil {
+		t.Error(fmt.Sprintf("Input at the limit rejected by DecodeFromRunes: %v", err))
+	}
+	if _, err := runeLimited.DecodeFromRunes(append(runes, 'x')); err != ErrInputTooLarge {
+		t.Error(fmt.Sprintf("Expected ErrInputTooLarge from DecodeFromRunes, got: %v", err))
+	}
+	// No limit by default.
+	if _, err := StdEncoding.EncodeChecked(make([]byte, 1<<20)); err != nil {
+		t.Error(fmt.Sprintf("Default encoding has a limit: %v", err))
+	}
+}
+
+func TestGraphemeBoundaries(t *testing.T) {
+	// The code points that may join a preceding or following one into a
+	// grapheme cluster, see UAX #29. The Extended_Pictographic code points
+	// above U+3000 in the BMP are listed explicitly, as package unicode
+	// doesn't have the property.
+	joining := []*unicode.RangeTable{
+		unicode.Mn, unicode.Me, unicode.Mc, unicode.Cc, unicode.Cf,
+		unicode.Other_Grapheme_Extend, unicode.Regional_Indicator, unicode.Prepended_Concatenation_Mark,
+		{R16: []unicode.Range16{{0x1100, 0x11ff, 1}, {0x3030, 0x303d, 13}, {0x3297, 0x3299, 2}}},
+	}
+	for _, enc := range []*Encoding{StdEncoding, SafeEncoding} {
+		runes := []rune{MARKER_SYMBOL, FILLER_SYMBOL}
+		for value := 0; value < 1<<enc.bitsPerRune; value += 1 {
+			runes = append(runes, enc.EncodeRune(uint16(value)))
+		}
+		for high := rune(0); high <= 0xff; high += 1 {
+			runes = append(runes, escapeHigh+high, escapeLow+high)
+		}
+		for _, r := range runes {
+			if unicode.IsOneOf(joining, r) {
+				t.Error(fmt.Sprintf("[%d] Glyph %U may join its neighbors", enc.bitsPerRune, r))
+			}
+			// Precomposed syllables are LV or LVT, neither of which joins a
+			// following LV or LVT.
+			if unicode.Is(unicode.Hangul, r) && (r < hangulBase || r > 0xd7a3) {
+				t.Error(fmt.Sprintf("[%d] Glyph %U is not a precomposed Hangul syllable", enc.bitsPerRune, r))
+			}
+		}
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, encoded := range encodeExpectedBytes {
+		f.Add(encoded)
+	}
+	// Padding symbols without any glyph, e.g. left over from truncation.
+	for digit := rune(0); digit < BITS_PER_RUNE; digit += 1 {
+		f.Add([]byte(string(PAD_START_SYMBOL + digit)))
+	}
+	f.Fuzz(func(t *testing.T, encoded []byte) {
+		decoded, err := Decode(encoded)
+		if err != nil {
+			return
+		}
+		if again, err := Decode(Encode(decoded)); err != nil || !bytes.Equal(again, decoded) {
+			t.Error(fmt.Sprintf("Round trip of %x from %q failed: %x (%v)", decoded, encoded, again, err))
+		}
+	})
+}
@@ -0,0 +1,45 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"errors"
+	"math/big"
+)
+
+// EncodeBigInt encodes the integer n into a base32k string.
+//
+// The encoded data is the big-endian magnitude of n without leading zero
+// bytes, so 0 encodes to the empty string. Negative numbers are marked with a
+// single leading zero byte, which a normalized magnitude never starts with.
+func EncodeBigInt(n *big.Int) (dest string) {
+	magnitude := n.Bytes()
+	if n.Sign() < 0 {
+		magnitude = append([]byte{0}, magnitude...)
+	}
+	return EncodeToString(magnitude)
+}
+
+// DecodeBigInt decodes a base32k string created by EncodeBigInt back into an
+// integer. Non-canonical encodings, i.e. ones with superfluous leading zero
+// bytes, are rejected so that every integer has exactly one encoding.
+func DecodeBigInt(s string) (n *big.Int, err error) {
+	magnitude, err := DecodeFromString(s)
+	if err != nil {
+		return nil, err
+	}
+	negative := len(magnitude) > 0 && magnitude[0] == 0
+	if negative {
+		magnitude = magnitude[1:]
+	}
+	if len(magnitude) > 0 && magnitude[0] == 0 || negative && len(magnitude) == 0 {
+		return nil, errors.New("Non-canonical integer encoding: leading zero bytes")
+	}
+	n = new(big.Int).SetBytes(magnitude)
+	if negative {
+		n.Neg(n)
+	}
+	return n, nil
+}
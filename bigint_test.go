@@ -0,0 +1,48 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestEncodeDecodeBigInt(t *testing.T) {
+	numbers := []string{
+		"0", "1", "-1", "255", "256", "-256", "65535",
+		"18446744073709551615", "18446744073709551616", "-18446744073709551616",
+		"340282366920938463463374607431768211455",
+		"-123456789012345678901234567890123456789012345678901234567890",
+	}
+	for _, number := range numbers {
+		t.Run(number, func(t *testing.T) {
+			n, _ := new(big.Int).SetString(number, 10)
+			decoded, err := DecodeBigInt(EncodeBigInt(n))
+			if err != nil {
+				t.Error(fmt.Sprintf("[%s] Error while decoding: %s", number, err))
+				return
+			}
+			if decoded.Cmp(n) != 0 {
+				t.Error(fmt.Sprintf("[%s] Expected %s, got %s", number, n, decoded))
+			}
+		})
+	}
+	if EncodeBigInt(big.NewInt(0)) != "" {
+		t.Error("Expected 0 to encode to the empty string")
+	}
+}
+
+func TestDecodeBigIntNonCanonical(t *testing.T) {
+	// Negative zero, and negative numbers with leading zeros.
+	for _, data := range [][]byte{{0}, {0, 0}, {0, 0, 1}} {
+		if _, err := DecodeBigInt(EncodeToString(data)); err == nil {
+			t.Error(fmt.Sprintf("Expected an error for magnitude %x", data))
+		}
+	}
+	if _, err := DecodeBigInt("缀x"); err == nil {
+		t.Error("Expected an error for invalid base32k")
+	}
+}
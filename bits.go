@@ -0,0 +1,132 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+// PackBits splits src into units of bitsPerUnit bits each, starting at the
+// least significant bits of each byte, the way base32k splits data into glyph
+// values. If the bits of src don't fill the final unit, pad is the number of
+// data bits it holds, i.e. the padding digit of base32k, and the unused high
+// bits are zero. Otherwise pad is 0. bitsPerUnit must be from 8 to 16.
+func PackBits(src []byte, bitsPerUnit int) (units []uint16, pad int) {
+	checkBitsPerUnit(bitsPerUnit)
+	br := bitReader[[]byte]{src: src, width: uint(bitsPerUnit)}
+	units = make([]uint16, 0, (len(src)*BYTE_LEN+bitsPerUnit-1)/bitsPerUnit)
+	for {
+		value, ok := br.read()
+		if !ok {
+			break
+		}
+		units = append(units, value)
+	}
+	if value, digits, ok := br.readLast(); ok {
+		units, pad = append(units, value), int(digits)
+	}
+	return units, pad
+}
+
+// UnpackBits joins units of bitsPerUnit bits each back into bytes, the
+// reverse of PackBits with the same pad. Bits of units beyond bitsPerUnit are
+// ignored, as are bits at the end that don't make up a full byte.
+func UnpackBits(units []uint16, bitsPerUnit, pad int) []byte {
+	checkBitsPerUnit(bitsPerUnit)
+	bw := bitWriter{width: uint(bitsPerUnit)}
+	dest := make([]byte, 0, len(units)*bitsPerUnit/BYTE_LEN+1)
+	for _, unit := range units {
+		data, n := bw.write(unit & (1<<bitsPerUnit - 1))
+		dest = append(dest, data[:n]...)
+	}
+	// The unused bits of the final unit may complete a byte of zero bits.
+	if pad > 0 && len(units) > 0 && bitsPerUnit-pad >= BYTE_LEN {
+		dest = dest[:len(dest)-1]
+	}
+	return dest
+}
+
+func checkBitsPerUnit(bitsPerUnit int) {
+	if bitsPerUnit < BYTE_LEN || bitsPerUnit > 16 {
+		panic("invalid bits per unit")
+	}
+}
+
+// bytesOrString is the data that can be encoded: a byte array, or a string of
+// bytes that is encoded without copying it.
+type bytesOrString interface{ ~[]byte | ~string }
+
+// bitReader reads glyph values of width bits from a byte array or string,
+// starting at the least significant bits of each byte.
+type bitReader[S bytesOrString] struct {
+	src   S
+	width uint
+	index uint // index of the byte holding the next bit
+	bit   uint // offset of the next bit into src[index]
+}
+
+// offset returns the bit offset of the next value into src.
+func (br *bitReader[S]) offset() int { return int(br.index*BYTE_LEN + br.bit) }
+
+// read reads the next value of width bits. It returns false and leaves the
+// reader unchanged if fewer bits are left in src.
+func (br *bitReader[S]) read() (value uint16, ok bool) {
+	index, bit, width := br.index, br.bit, br.width
+	if index+(bit+width+BYTE_LEN-1)/BYTE_LEN > uint(len(br.src)) {
+		return 0, false
+	}
+	value = uint16(br.src[index] >> bit)
+	if bit+width > BYTE_LEN {
+		value += uint16(br.src[index+1]) << (BYTE_LEN - bit)
+	}
+	if bit+width > BYTE_LEN*2 { // we skipped too many bits of the first byte & thus need some of the third byte as well
+		value += uint16(br.src[index+2]) << (BYTE_LEN*2 - bit)
+	}
+	value &= 1<<width - 1
+	br.index = index + (bit+width)/BYTE_LEN
+	br.bit = (bit + width) % BYTE_LEN
+	return value, true
+}
+
+// readLast reads the bits left over after read failed, i.e. fewer than width
+// bits, and returns them along with their number (the digits). It returns false
+// if there are no bits left.
+func (br *bitReader[S]) readLast() (value uint16, digits uint, ok bool) {
+	index, bit := br.index, br.bit
+	switch uint(len(br.src)) - index {
+	case 2:
+		value = uint16(br.src[index] >> bit)
+		value += uint16(br.src[index+1]) << (BYTE_LEN - bit)
+		digits = BYTE_LEN*2 - bit
+	case 1:
+		value = uint16(br.src[index] >> bit)
+		digits = BYTE_LEN - bit
+	default:
+		return 0, 0, false
+	}
+	br.index, br.bit = uint(len(br.src)), 0
+	return value, digits, true
+}
+
+// bitWriter assembles bytes from glyph values of width bits, the reverse of
+// bitReader. The bits of an incomplete byte are carried over to the next
+// value.
+type bitWriter struct {
+	width     uint
+	remainder byte // the carried bits
+	bit       uint // the number of carried bits
+}
+
+// write adds value to the carried bits and returns the n bytes completed by
+// it, which is 1 or 2. The bytes are returned in an array instead of a slice
+// so that decoding doesn't allocate per glyph.
+func (bw *bitWriter) write(value uint16) (data [2]byte, n int) {
+	bit, width := bw.bit, bw.width
+	data[0], n = byte(value<<bit)+bw.remainder, 1
+	if bit+width >= BYTE_LEN*2 { // a complete second byte is available
+		data[1], n = byte(value>>(BYTE_LEN-bit)), 2
+		bw.remainder = byte(value >> (BYTE_LEN*2 - bit))
+	} else {
+		bw.remainder = byte(value >> (BYTE_LEN - bit))
+	}
+	bw.bit = (bit + width) % BYTE_LEN
+	return
+}
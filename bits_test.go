@@ -0,0 +1,238 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestBitReaderRead(t *testing.T) {
+	data := []byte{0xf0, 0xa5, 0x5a, 0xa5}
+	// 11110000 10100101 01011010 10100101
+
+	// 0010 0101 1111 0000 => 25f0
+	// 0101 0010 1111 1000 => 52f8
+	// 0010 1001 0111 1100 => 297c
+	// 0101 0100 1011 1110 => 54be
+	// 0010 1010 0101 1111 => 2a5f
+	// 0101 0101 0010 1111 => 552f
+	// 0110 1010 1001 0111 => 6a97
+	// 0011 0101 0100 1011 => 354b
+
+	i := uint(0)
+	expectedIndices := []uint{1, 2, 2, 2, 2, 2, 2, 2}
+	expectedBits := []uint{7, 0, 1, 2, 3, 4, 5, 6}
+	expectedValues := []uint16{0x25f0, 0x52f8, 0x297c, 0x54be, 0x2a5f, 0x552f, 0x6a97, 0x354b}
+	for _, b := range []uint{0, 1, 2, 3, 4, 5, 6, 7} {
+		t.Run(fmt.Sprintf("bit_offset_%d", b), func(t *testing.T) {
+			br := bitReader[[]byte]{src: data, width: BITS_PER_RUNE, index: i, bit: b}
+			value, ok := br.read()
+			newIndex, newBit := br.index, br.bit
+			if !ok {
+				t.Error(fmt.Sprintf("[b=%d] end of input reached", b))
+			}
+			if newIndex != expectedIndices[b] {
+				t.Error(fmt.Sprintf("[b=%d] index incorrect, expected: %d, got: %d", b, expectedIndices[b], newIndex))
+			}
+			if newBit != expectedBits[b] {
+				t.Error(fmt.Sprintf("[b=%d] bit index incorrect, expected: %d, got: %d", b, expectedBits[b], newBit))
+			}
+			if value != expectedValues[b] {
+				t.Error(fmt.Sprintf("[b=%d] value incorrect, expected: 0x%0.2x, got: 0x%0.2x", b, expectedValues[b], value))
+			}
+		})
+	}
+}
+
+func TestBitReaderReadLast(t *testing.T) {
+	data := []byte{0xf0, 0xa5}
+	for _, b := range []uint{0, 1, 2, 3, 4, 5, 6, 7} {
+		t.Run(fmt.Sprintf("bit_offset_%d", b), func(t *testing.T) {
+			// The remaining digits are exactly the remaining bits of the input.
+			for i := uint(0); i < uint(len(data)); i += 1 {
+				br := bitReader[[]byte]{src: data, width: BITS_PER_RUNE, index: i, bit: b}
+				_, digits, ok := br.readLast()
+				if !ok {
+					t.Error(fmt.Sprintf("[b=%d] end of input reached", b))
+				}
+				if expected := (uint(len(data))-i)*BYTE_LEN - b; digits != expected {
+					t.Error(fmt.Sprintf("[b=%d] digits incorrect, expected: %d, got: %d", b, expected, digits))
+				}
+			}
+		})
+	}
+	// At the end of the input there is no last rune, and no padding symbol.
+	br := bitReader[[]byte]{src: data, width: BITS_PER_RUNE, index: uint(len(data))}
+	if _, digits, ok := br.readLast(); ok || digits != 0 {
+		t.Error(fmt.Sprintf("Last rune past the end of input: %d digits", digits))
+	}
+}
+
+func TestBitWriterWrite(t *testing.T) {
+	runes := []rune{0x25f0, 0x52f8, 0x297c, 0x54be, 0x2a5f, 0x552f, 0x6a97, 0x354b}
+	expectedBytes := map[uint]struct {
+		data      []byte
+		remainder byte
+		bit       uint
+	}{
+		// 010 0101 1111 0000
+		0: {[]byte{0xf0}, 0x25, 7},
+		// 0100 1011 1110 000.
+		1: {[]byte{0xe0, 0x4b}, 0x00, 0},
+		// 0 1001 0111 1100 00..
+		2: {[]byte{0xc0, 0x97}, 0x00, 1},
+		// 01 0010 1111 1000 0...
+		3: {[]byte{0x80, 0x2f}, 0x01, 2},
+		// 010 0101 1111 0000 ....
+		4: {[]byte{0x00, 0x5f}, 0x02, 3},
+		// 0100 1011 1110 000. ....
+		5: {[]byte{0x00, 0xbe}, 0x04, 4},
+		// 0 1001 0111 1100 00.. ....
+		6: {[]byte{0x00, 0x7c}, 0x09, 5},
+		// 01 0010 1111 1000 0... ....
+		7: {[]byte{0x00, 0xf8}, 0x012, 6},
+	}
+	for b, expected := range expectedBytes {
+		t.Run(fmt.Sprintf("bit_offset_%d", b), func(t *testing.T) {
+			bw := bitWriter{width: BITS_PER_RUNE, bit: b}
+			written, n := bw.write(uint16(runes[0]))
+			data := written[:n]
+			remainder, bit := bw.remainder, bw.bit
+			if bit != expected.bit {
+				t.Error(fmt.Sprintf("[%d] bit index incorrect, expected: %d, got: %d", b, expected.bit, bit))
+			}
+			if remainder != expected.remainder {
+				t.Error(fmt.Sprintf("[%d] remainder incorrect, expected: 0x%0.2x, got: 0x%0.2x", b, expected.remainder, remainder))
+			}
+			if len(data) > len(expected.data) {
+				t.Error(fmt.Sprintf("[%d] data too long: %d (need: %d)", b, len(data), len(expected.data)))
+			}
+			if len(data) < len(expected.data) {
+				t.Error(fmt.Sprintf("[%d] data too short: %d (need: %d)", b, len(data), len(expected.data)))
+			}
+			for j, b := range data {
+				if b != expected.data[j] {
+					t.Error(fmt.Sprintf("[%d](%d) data incorrect, expected: 0x%0.2x, got: 0x%0.2x", b, j, expected.data[j], b))
+				}
+			}
+		})
+	}
+}
+
+func TestBitRoundTrip(t *testing.T) {
+	for _, width := range []uint{14, 15} {
+		t.Run(fmt.Sprintf("width_%d", width), func(t *testing.T) {
+			br := bitReader[[]byte]{src: srcData, width: width}
+			bw := bitWriter{width: width}
+			var data []byte
+			for {
+				value, ok := br.read()
+				if !ok {
+					break
+				}
+				written, n := bw.write(value)
+				data = append(data, written[:n]...)
+			}
+			if value, digits, ok := br.readLast(); ok {
+				written, n := bw.write(value)
+				data = append(data, written[:n]...)
+				// Drop the byte of zero bits completed by the last value.
+				if width-digits >= BYTE_LEN {
+					data = data[:len(data)-1]
+				}
+			}
+			if string(data) != string(srcData) {
+				t.Error(fmt.Sprintf("[%d] Round trip mismatch: %x", width, data))
+			}
+		})
+	}
+}
+
+// referenceBits extracts count bits starting at bit offset of src one by one,
+// least significant bit of each byte first, as the bit layout of the encoding
+// prescribes.
+func referenceBits(src []byte, offset, count int) (value uint16) {
+	for k := 0; k < count; k += 1 {
+		bit := src[(offset+k)/BYTE_LEN] >> ((offset + k) % BYTE_LEN) & 1
+		value |= uint16(bit) << k
+	}
+	return value
+}
+
+func TestBitReaderTable(t *testing.T) {
+	src := make([]byte, 17)
+	rand.Read(src)
+	for _, width := range []uint{8, 12, 14, 15, 16} {
+		for length := 1; length <= len(src); length += 1 {
+			data := src[:length]
+			for offset := 0; offset < length*BYTE_LEN; offset += 1 {
+				name := fmt.Sprintf("width_%d_length_%d_offset_%d", width, length, offset)
+				index, bit := uint(offset/BYTE_LEN), uint(offset%BYTE_LEN)
+				br := bitReader[[]byte]{src: data, width: width, index: index, bit: bit}
+				value, ok := br.read()
+				left := length*BYTE_LEN - offset
+				if left >= int(width) {
+					end := offset + int(width)
+					if !ok || value != referenceBits(data, offset, int(width)) || br.offset() != end {
+						t.Fatal(fmt.Sprintf("[%s] read 0x%04x at %d (%v), expected 0x%04x at %d",
+							name, value, br.offset(), ok, referenceBits(data, offset, int(width)), end))
+					}
+					continue
+				}
+				if ok || br.index != index || br.bit != bit {
+					t.Fatal(fmt.Sprintf("[%s] read past the end of input", name))
+				}
+				value, digits, ok := br.readLast()
+				if !ok || int(digits) != left || value != referenceBits(data, offset, left) {
+					t.Fatal(fmt.Sprintf("[%s] last value 0x%04x with %d digits (%v), expected 0x%04x with %d",
+						name, value, digits, ok, referenceBits(data, offset, left), left))
+				}
+				if _, _, ok = br.readLast(); ok {
+					t.Fatal(fmt.Sprintf("[%s] read last value twice", name))
+				}
+			}
+		}
+	}
+}
+
+func TestPackBits(t *testing.T) {
+	src := make([]byte, 40)
+	rand.Read(src)
+	for _, bitsPerUnit := range []int{8, 12, 15, 16} {
+		for length := 0; length <= len(src); length += 1 {
+			name := fmt.Sprintf("bits_%d_length_%d", bitsPerUnit, length)
+			data := src[:length]
+			units, pad := PackBits(data, bitsPerUnit)
+			if expected := (length*BYTE_LEN + bitsPerUnit - 1) / bitsPerUnit; len(units) != expected {
+				t.Fatal(fmt.Sprintf("[%s] Packed into %d units, expected %d", name, len(units), expected))
+			}
+			if expected := length * BYTE_LEN % bitsPerUnit; pad != expected {
+				t.Error(fmt.Sprintf("[%s] Pad is %d, expected %d", name, pad, expected))
+			}
+			for i, unit := range units {
+				width := min(bitsPerUnit, length*BYTE_LEN-i*bitsPerUnit)
+				if expected := referenceBits(data, i*bitsPerUnit, width); unit != expected {
+					t.Error(fmt.Sprintf("[%s] Unit %d is 0x%04x, expected 0x%04x", name, i, unit, expected))
+				}
+			}
+			if unpacked := UnpackBits(units, bitsPerUnit, pad); string(unpacked) != string(data) {
+				t.Error(fmt.Sprintf("[%s] Unpacked to %x, expected %x", name, unpacked, data))
+			}
+		}
+	}
+	// With 15 bits, the units are the glyph values and pad the padding digit.
+	units, pad := PackBits(srcData, BITS_PER_RUNE)
+	runes := []rune(encodeExpectedStrings[16])
+	for i, unit := range units {
+		if value, _ := DecodeRune(runes[i]); unit != value {
+			t.Error(fmt.Sprintf("[%d] Unit 0x%04x doesn't match glyph %q", i, unit, runes[i]))
+		}
+	}
+	if rune(pad) != runes[len(runes)-1]-PAD_START_SYMBOL {
+		t.Error(fmt.Sprintf("Pad %d doesn't match padding symbol %q", pad, runes[len(runes)-1]))
+	}
+}
@@ -0,0 +1,73 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"errors"
+	"hash/crc32"
+)
+
+// ErrChecksumMismatch is returned by DecodeWithChecksum if the checksum
+// doesn't match the decoded data, or if the input is too short to hold one.
+var ErrChecksumMismatch = errors.New("Checksum mismatch")
+
+// EncodeWithChecksum encodes a given byte array of data with StdEncoding and a
+// checksum, see Encoding.EncodeWithChecksum.
+func EncodeWithChecksum(src []byte) string { return StdEncoding.EncodeWithChecksum(src) }
+
+// DecodeWithChecksum decodes a string created by EncodeWithChecksum, see
+// Encoding.DecodeWithChecksum.
+func DecodeWithChecksum(s string) (dest []byte, err error) { return StdEncoding.DecodeWithChecksum(s) }
+
+// EncodeWithChecksum encodes a given byte array of data into a base32k string
+// with two extra glyphs holding a CRC-32 of the data (the lowest 30 bits for
+// StdEncoding), to detect text that was mangled in transport. The checksum
+// glyphs follow the data glyphs, and the padding symbol, if any, still comes
+// last, so it can't be mistaken for a checksum glyph. This costs 2 glyphs per
+// message.
+func (enc *Encoding) EncodeWithChecksum(src []byte) string {
+	runes := enc.EncodeToRunes(src)
+	sum := crc32.ChecksumIEEE(src)
+	checksum := []rune{
+		enc.EncodeRune(uint16(sum)),
+		enc.EncodeRune(uint16(sum >> enc.bitsPerRune)),
+	}
+	if len(runes) > 0 && enc.isPadding(runes[len(runes)-1]) {
+		padding := runes[len(runes)-1]
+		return string(append(append(runes[:len(runes)-1], checksum...), padding))
+	}
+	return string(append(runes, checksum...))
+}
+
+// DecodeWithChecksum decodes a string created by EncodeWithChecksum and
+// verifies its checksum. It returns ErrChecksumMismatch if the checksum
+// doesn't match, and the usual decoding errors if the data glyphs are invalid.
+func (enc *Encoding) DecodeWithChecksum(s string) (dest []byte, err error) {
+	runes := []rune(s)
+	end := len(runes)
+	if end > 0 && enc.isPadding(runes[end-1]) {
+		end -= 1
+	}
+	if end < 2 {
+		return nil, ErrChecksumMismatch
+	}
+	var sum uint32
+	for i, r := range runes[end-2 : end] {
+		value, ok := enc.DecodeRune(r)
+		if !ok {
+			return nil, CorruptInputError{end - 2 + i, r, "Invalid checksum glyph"}
+		}
+		sum |= uint32(value) << (uint(i) * enc.bitsPerRune)
+	}
+	data := append(runes[:end-2:end-2], runes[end:]...)
+	if dest, err = enc.DecodeFromRunes(data); err != nil {
+		return nil, err
+	}
+	mask := uint32(1)<<(2*enc.bitsPerRune) - 1 // all ones for BMPEncoding
+	if crc32.ChecksumIEEE(dest)&mask != sum {
+		return nil, ErrChecksumMismatch
+	}
+	return dest, nil
+}
@@ -0,0 +1,68 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEncodeWithChecksum(t *testing.T) {
+	for _, enc := range []*Encoding{StdEncoding, SafeEncoding, BMPEncoding} {
+		for n := range encodeExpectedStrings {
+			t.Run(fmt.Sprintf("bits_%d_data_size_%d", enc.bitsPerRune, n), func(t *testing.T) {
+				encoded := enc.EncodeWithChecksum(srcData[:n])
+				plain := enc.EncodeToString(srcData[:n])
+				if utf8.RuneCountInString(encoded) != utf8.RuneCountInString(plain)+2 {
+					t.Error(fmt.Sprintf("[%d] Checksum doesn't take up 2 glyphs: %s", n, encoded))
+				}
+				decoded, err := enc.DecodeWithChecksum(encoded)
+				if err != nil || !bytes.Equal(decoded, srcData[:n]) {
+					t.Error(fmt.Sprintf("[%d] Round trip failed: %x (%v)", n, decoded, err))
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeWithChecksumCorrupted(t *testing.T) {
+	for n := range encodeExpectedStrings {
+		runes := []rune(EncodeWithChecksum(srcData[:n]))
+		// The last data glyph of a padded encoding only holds the bits given
+		// by the padding digit, the others are ignored by the decoder.
+		lastData, digits := -1, BITS_PER_RUNE
+		if padding := runes[len(runes)-1]; StdEncoding.isPadding(padding) {
+			lastData, digits = len(runes)-4, int(padding-PAD_START_SYMBOL)
+		}
+		// Flip each data bit of each glyph value.
+		for i, r := range runes {
+			value, ok := DecodeRune(r)
+			if !ok {
+				continue // padding symbol
+			}
+			bits := BITS_PER_RUNE
+			if i == lastData {
+				bits = digits
+			}
+			for bit := 0; bit < bits; bit += 1 {
+				corrupted := append([]rune{}, runes...)
+				corrupted[i] = EncodeRune(value ^ 1<<bit)
+				if _, err := DecodeWithChecksum(string(corrupted)); err == nil {
+					t.Error(fmt.Sprintf("[%d] Flipped bit %d of glyph %d not detected", n, bit, i))
+				}
+			}
+		}
+	}
+	for n, encoded := range encodeExpectedStrings {
+		if _, err := DecodeWithChecksum(encoded); n > 0 && err == nil {
+			t.Error(fmt.Sprintf("[%d] Missing checksum not detected", n))
+		}
+	}
+	if _, err := DecodeWithChecksum("耀"); err != ErrChecksumMismatch {
+		t.Error(fmt.Sprintf("Expected ErrChecksumMismatch for a short input, got: %v", err))
+	}
+}
@@ -0,0 +1,96 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import "errors"
+
+// ErrFinished is returned by DecodeChunk for a chunk following the final one.
+var ErrFinished = errors.New("Decoding already finished")
+
+// A DecoderState holds what decoding has carried over between the chunks
+// passed to DecodeChunk: the bits of an incomplete byte, the last byte decoded
+// so far and what the end of the input is checked against. The fields are
+// exported so that the state can be serialized, e.g. with encoding/json, to
+// resume decoding in another process, but aren't meant to be changed. The
+// zero value is the state at the start of the input.
+type DecoderState struct {
+	Remainder byte   // the carried bits
+	Bit       uint   // the number of carried bits
+	Held      []byte // the last decoded byte, which a padding symbol may remove
+	Runes     int    // the number of runes decoded so far
+	Glyphs    int    // the number of glyphs, not counting a length glyph
+	Last      uint16 // the value of the last glyph
+	// Length is the value of the length glyph, once LengthRead is set.
+	Length     uint16
+	LengthRead bool
+	// BOM and Marked are set once a byte order mark and the marker have
+	// been skipped at the start of the input.
+	BOM, Marked bool
+	Finished    bool
+}
+
+// DecodeChunk decodes the next chunk of StdEncoding runes, see
+// Encoding.DecodeChunk.
+func DecodeChunk(state *DecoderState, runes []rune) ([]byte, error) {
+	return StdEncoding.DecodeChunk(state, runes)
+}
+
+// DecodeChunk decodes the next chunk of the runes of an encoding and updates
+// state, for decodes that are too large to redo after a restart: state can be
+// saved after every chunk and decoding resumed from there. Concatenated, the
+// results are the decoded data.
+//
+// A chunk that ends in a padding symbol is the final one, and an empty chunk
+// ends an encoding without padding symbol. The final chunk checks the end of
+// the input like Decode does, and returns the last byte, which is held back
+// in state until then. Chunks must not split the escape sequence of an
+// excluded glyph or a decomposed Hangul syllable. Error positions count the
+// runes of all chunks. On an error, state is left unchanged.
+func (enc *Encoding) DecodeChunk(state *DecoderState, runes []rune) ([]byte, error) {
+	if state.Finished {
+		return nil, ErrFinished
+	}
+	d := newDecoder(enc)
+	d.bits.remainder, d.bits.bit = state.Remainder, state.Bit
+	d.last, d.glyphs = state.Last, state.Glyphs
+	d.length, d.lengthRead = state.Length, state.LengthRead
+	d.started = state.Glyphs > 0 || state.LengthRead
+	d.bom, d.marked = state.BOM, state.Marked
+	d.out = make([]byte, 0, len(state.Held)+enc.MaxDecodedLen(len(runes)))
+	d.out = append(d.out, state.Held...)
+	for i, r := range runes {
+		if err := d.decodeRune(state.Runes+i, r, i == len(runes)-1); err != nil {
+			return nil, err
+		}
+	}
+	final := len(runes) == 0 || d.padded
+	if final {
+		if err := d.finish(); err != nil {
+			return nil, err
+		}
+	} else if err := d.flushJamo(); err != nil {
+		return nil, err
+	} else if d.escape != 0 {
+		return nil, CorruptInputError{d.escapeIndex, d.escape, "Incomplete escape sequence"}
+	}
+	out, held := d.out, []byte(nil)
+	if !final && len(out) > 0 {
+		out, held = out[:len(out)-1], []byte{out[len(out)-1]}
+	}
+	*state = DecoderState{
+		Remainder:  d.bits.remainder,
+		Bit:        d.bits.bit,
+		Held:       held,
+		Runes:      state.Runes + len(runes),
+		Glyphs:     d.glyphs,
+		Last:       d.last,
+		Length:     d.length,
+		LengthRead: d.lengthRead,
+		BOM:        d.bom,
+		Marked:     d.marked,
+		Finished:   final,
+	}
+	return out, nil
+}
@@ -0,0 +1,122 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// decodeChunks decodes runes in chunks split at the given rune indexes, with
+// the state going through JSON in between. An empty chunk would end the
+// input, so there is one only at the end, if the runes don't end in a padding
+// symbol.
+func decodeChunks(enc *Encoding, runes []rune, splits []int) (dest []byte, err error) {
+	var state DecoderState
+	start := 0
+	for _, split := range append(splits, len(runes)) {
+		if split == start {
+			continue
+		}
+		chunk, err := enc.DecodeChunk(&state, runes[start:split])
+		if err != nil {
+			return nil, err
+		}
+		dest, start = append(dest, chunk...), split
+		saved, _ := json.Marshal(state)
+		state = DecoderState{}
+		json.Unmarshal(saved, &state)
+	}
+	if !state.Finished {
+		chunk, err := enc.DecodeChunk(&state, nil)
+		if err != nil {
+			return nil, err
+		}
+		dest = append(dest, chunk...)
+	}
+	return dest, nil
+}
+
+func TestDecodeChunk(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		runes := []rune(encoded)
+		for split := 0; split <= len(runes); split += 1 {
+			decoded, err := decodeChunks(StdEncoding, runes, []int{split})
+			if err != nil || !bytes.Equal(decoded, srcData[:n]) {
+				t.Error(fmt.Sprintf("[%d] Split at %d decoded to %v (%v)", n, split, decoded, err))
+			}
+		}
+	}
+	random := rand.New(rand.NewSource(1))
+	data := make([]byte, 500)
+	random.Read(data)
+	encodings := []*Encoding{StdEncoding, SafeEncoding, BMPEncoding, StdEncoding.WithPadding(NoPadding),
+		StdEncoding.WithMarker(), StdEncoding.WithLengthGlyph()}
+	for _, enc := range encodings {
+		for n := 0; n <= len(data); n += 7 {
+			runes := enc.EncodeToRunes(data[:n])
+			var splits []int
+			for split := 0; split < len(runes); split += random.Intn(20) {
+				splits = append(splits, split)
+			}
+			expected, expectedErr := enc.DecodeFromRunes(runes)
+			if decoded, err := decodeChunks(enc, runes, splits); err != expectedErr || !bytes.Equal(decoded, expected) {
+				t.Error(fmt.Sprintf("[%s/%d] Chunks %v decoded to %v (%v)", enc, n, splits, decoded, err))
+			}
+		}
+	}
+}
+
+func TestDecodeChunkPrefix(t *testing.T) {
+	// A chunk of only the byte order mark, or of the byte order mark and the
+	// marker, is followed by the glyphs.
+	enc := StdEncoding.WithMarker()
+	runes := append([]rune{byteOrderMark}, enc.EncodeToRunes(srcData[:9])...)
+	for _, split := range []int{1, 2} {
+		if decoded, err := decodeChunks(enc, runes, []int{split}); err != nil || !bytes.Equal(decoded, srcData[:9]) {
+			t.Error(fmt.Sprintf("Split at %d decoded to %v (%v)", split, decoded, err))
+		}
+	}
+	// The state keeps the byte order mark or marker from being skipped
+	// again.
+	for _, split := range []int{1, 2} {
+		duplicated := append(append([]rune{}, runes[:split]...), runes[split-1:]...)
+		if _, err := decodeChunks(enc, duplicated, []int{split}); err == nil {
+			t.Error(fmt.Sprintf("Expected an error for rune %U repeated after the split", duplicated[split]))
+		}
+	}
+}
+
+func TestDecodeChunkErrors(t *testing.T) {
+	// Positions count the runes of all chunks.
+	runes := []rune(encodeExpectedStrings[16])
+	runes[5] = '!'
+	_, err := decodeChunks(StdEncoding, runes, []int{3})
+	if corrupt, ok := err.(CorruptInputError); !ok || corrupt.Position != 5 {
+		t.Error(fmt.Sprintf("Expected an error at rune 5, got %v", err))
+	}
+	// A truncated encoding is detected by the empty final chunk.
+	var state DecoderState
+	if _, err = DecodeChunk(&state, []rune(encodeExpectedStrings[4])[:2]); err != nil {
+		t.Error(fmt.Sprintf("Expected no error for the first chunk, got %v", err))
+	}
+	saved := state
+	if _, err = DecodeChunk(&state, nil); err != ErrUnexpectedEnd {
+		t.Error(fmt.Sprintf("Expected ErrUnexpectedEnd, got %v", err))
+	}
+	if fmt.Sprint(state) != fmt.Sprint(saved) {
+		t.Error(fmt.Sprintf("Expected the state to be unchanged by the error, got %+v", state))
+	}
+	if _, err = DecodeChunk(&state, []rune(encodeExpectedStrings[4])[2:]); err != nil {
+		t.Error(fmt.Sprintf("Expected the rest to decode after the error, got %v", err))
+	}
+	if _, err = DecodeChunk(&state, nil); !errors.Is(err, ErrFinished) {
+		t.Error(fmt.Sprintf("Expected ErrFinished after the final chunk, got %v", err))
+	}
+}
@@ -0,0 +1,84 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+// CompareRatios returns how many bytes of data fit into a message of limit
+// units of a medium, where a CJK or Hangul glyph costs charCostCJK units and
+// an ASCII character charCostASCII units, when encoded with "base32k",
+// "base64" (padded, as in encoding/base64.StdEncoding) and "base122". For
+// twitter, which counts 280 characters and a CJK glyph as two, this is
+// CompareRatios(280, 2, 1), giving the bytes per tweet in the package
+// documentation.
+//
+// The base32k numbers follow from StdEncoding.EncodedLength, including the
+// ASCII padding symbol. The characters of base64 and base122 are all counted
+// as ASCII. Non-positive costs panic.
+func CompareRatios(limit, charCostCJK, charCostASCII int) map[string]int {
+	if charCostCJK <= 0 || charCostASCII <= 0 {
+		panic("invalid character cost")
+	}
+	costs := map[string]func(n int) int{
+		"base32k": func(n int) int {
+			glyphs, padding := StdEncoding.encodedRunes(n)
+			if padding != NoPadding {
+				return glyphs*charCostCJK + charCostASCII
+			}
+			return glyphs * charCostCJK
+		},
+		"base64": func(n int) int { return (n + 2) / 3 * 4 * charCostASCII },
+		"base122": func(n int) int {
+			return (n/7*8 + (n%7*8+6)/7) * charCostASCII
+		},
+	}
+	fits := map[string]int{}
+	for name, cost := range costs {
+		fits[name] = maxFitting(limit, cost)
+	}
+	return fits
+}
+
+// maxFitting returns the largest data length whose cost is at most limit. A
+// unit of cost carries less than 2 bytes in any of the encodings, which bounds
+// the search.
+func maxFitting(limit int, cost func(n int) int) int {
+	lo, hi := 0, max(limit, 0)*2+1 // cost(lo) <= limit < cost(hi)
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if cost(mid) <= limit {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// BytesPerMessage returns how many bytes of data StdEncoding fits into a
+// message of maxChars characters, see Encoding.BytesPerMessage.
+func BytesPerMessage(maxChars int, charsPerGlyph int) int {
+	return StdEncoding.BytesPerMessage(maxChars, charsPerGlyph)
+}
+
+// BytesPerMessage returns how many bytes of data fit into a message of
+// maxChars characters, on a platform that counts each glyph as charsPerGlyph
+// characters, i.e. the largest length whose encoding fits. The padding symbol
+// counts as a single character, as it is ASCII. For twitter this is
+// BytesPerMessage(280, 2), the bytes per tweet in the package documentation,
+// for platforms that count graphemes it's BytesPerMessage(limit, 1).
+//
+// The escapes of an encoding with exclusions depend on the data and aren't
+// accounted for. A non-positive charsPerGlyph panics.
+func (enc *Encoding) BytesPerMessage(maxChars int, charsPerGlyph int) int {
+	if charsPerGlyph <= 0 {
+		panic("invalid characters per glyph")
+	}
+	return maxFitting(maxChars, func(n int) int {
+		glyphs, padding := enc.encodedRunes(n)
+		if padding != NoPadding {
+			return glyphs*charsPerGlyph + 1
+		}
+		return glyphs * charsPerGlyph
+	})
+}
@@ -0,0 +1,74 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCompareRatios(t *testing.T) {
+	// The bytes per tweet of the table in the package documentation.
+	expected := map[string]int{"base64": 210, "base122": 245, "base32k": 260}
+	for name, bytes := range CompareRatios(280, 2, 1) {
+		if bytes != expected[name] {
+			t.Error(fmt.Sprintf("[%s] Expected %d bytes per tweet, got %d", name, expected[name], bytes))
+		}
+	}
+	// The lengths fit, one more byte doesn't.
+	for _, limit := range []int{0, 1, 2, 3, 100, 280, 500, 1000} {
+		for _, cjk := range []int{1, 2, 3} {
+			fits := CompareRatios(limit, cjk, 1)
+			for n := fits["base32k"]; n <= fits["base32k"]+1; n += 1 {
+				encoded := EncodeToString(make([]byte, n))
+				runes := utf8.RuneCountInString(encoded)
+				cost := runes * cjk
+				if n%BITS_PER_RUNE != 0 {
+					cost -= cjk - 1 // the padding symbol
+				}
+				if (cost <= limit) != (n == fits["base32k"]) {
+					t.Error(fmt.Sprintf("[%d/%d] %d bytes cost %d", limit, cjk, n, cost))
+				}
+			}
+			for n := fits["base64"]; n <= fits["base64"]+1; n += 1 {
+				if cost := base64.StdEncoding.EncodedLen(n); (cost <= limit) != (n == fits["base64"]) {
+					t.Error(fmt.Sprintf("[%d/%d] %d bytes cost %d in base64", limit, cjk, n, cost))
+				}
+			}
+		}
+	}
+}
+
+func TestBytesPerMessage(t *testing.T) {
+	// Guards the bytes per tweet of the table in the package documentation.
+	if bytes := BytesPerMessage(280, 2); bytes != 260 {
+		t.Error(fmt.Sprintf("Expected the documented 260 bytes per tweet, got %d", bytes))
+	}
+	if bytes := BytesPerMessage(280, 2); bytes != CompareRatios(280, 2, 1)["base32k"] {
+		t.Error(fmt.Sprintf("BytesPerMessage and CompareRatios disagree: %d", bytes))
+	}
+	for _, enc := range []*Encoding{StdEncoding, SafeEncoding, StdEncoding.WithPadding(NoPadding), StdEncoding.WithMarker()} {
+		for _, maxChars := range []int{0, 1, 2, 3, 17, 280, 500} {
+			for _, perGlyph := range []int{1, 2} {
+				fits := enc.BytesPerMessage(maxChars, perGlyph)
+				for n := fits; n <= fits+1; n += 1 {
+					cost := 0
+					for _, r := range enc.EncodeToString(make([]byte, n)) {
+						if r < utf8.RuneSelf {
+							cost += 1
+						} else {
+							cost += perGlyph
+						}
+					}
+					if (cost <= maxChars) != (n == fits) {
+						t.Error(fmt.Sprintf("[%d/%d] %d bytes cost %d", maxChars, perGlyph, n, cost))
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import "unicode/utf8"
+
+// Concat merges the StdEncoding strings a and b into one, see
+// Encoding.Concat.
+func Concat(a, b string) (dest string, err error) { return StdEncoding.Concat(a, b) }
+
+// Concat merges the encodings a and b into a single encoding of the data of a
+// followed by the data of b. Simply joining the strings doesn't work, as the
+// padding symbol of a would end up in the middle, and the glyphs of b
+// wouldn't line up with the bits left over in the last glyph of a. Instead,
+// both are decoded and the joined data is encoded again, which takes time and
+// memory linear in the length of both. Use EncodeFields to keep the encodings
+// apart instead.
+//
+// The Position of a CorruptInputError in b counts the runes of a as well, as
+// if the strings were joined.
+func (enc *Encoding) Concat(a, b string) (dest string, err error) {
+	first, err := enc.DecodeFromString(a)
+	if err != nil {
+		return "", err
+	}
+	second, err := enc.DecodeFromString(b)
+	if corrupt, ok := err.(CorruptInputError); ok {
+		corrupt.Position += utf8.RuneCountInString(a)
+		return "", corrupt
+	} else if err != nil {
+		return "", err
+	}
+	return enc.EncodeToString(append(first, second...)), nil
+}
@@ -0,0 +1,37 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestConcat(t *testing.T) {
+	for n := range encodeExpectedStrings {
+		for m := range encodeExpectedStrings {
+			x, y := srcData[:n], srcData[len(srcData)-m:]
+			t.Run(fmt.Sprintf("data_size_%d_%d", n, m), func(t *testing.T) {
+				joined, err := Concat(EncodeToString(x), EncodeToString(y))
+				if err != nil {
+					t.Fatal(fmt.Sprintf("[%d/%d] Error while joining: %s", n, m, err))
+				}
+				decoded, err := DecodeFromString(joined)
+				if expected := append(bytes.Clone(x), y...); err != nil || !bytes.Equal(decoded, expected) {
+					t.Error(fmt.Sprintf("[%d/%d] Expected %x, got %x (%v)", n, m, expected, decoded, err))
+				}
+			})
+		}
+	}
+	var corrupt CorruptInputError
+	if _, err := Concat(encodeExpectedStrings[4], "缀x"); !errors.As(err, &corrupt) || corrupt.Position != 5 {
+		t.Error(fmt.Sprintf("Expected CorruptInputError at position 5, got: %v", err))
+	}
+	if _, err := Concat("x", encodeExpectedStrings[4]); !errors.As(err, &corrupt) || corrupt.Position != 0 {
+		t.Error(fmt.Sprintf("Expected CorruptInputError at position 0, got: %v", err))
+	}
+}
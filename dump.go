@@ -0,0 +1,81 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// String describes the configuration of the encoding, e.g. for logging which
+// encoding is in use.
+func (enc *Encoding) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "base32k.Encoding{bits: %d, lanes:", enc.bitsPerRune)
+	if enc.bmp {
+		fmt.Fprintf(&b, " U+%04X-U+%04X", bmpOffset, enc.valueToRune(1<<enc.bitsPerRune-1))
+	} else {
+		for _, prefix := range enc.toLane {
+			fmt.Fprintf(&b, " U+%04X", prefix)
+		}
+	}
+	if !enc.HasPadding() {
+		b.WriteString(", padding: none")
+	} else {
+		fmt.Fprintf(&b, ", padding: %q-%q", enc.padStart+1, enc.padStart+rune(enc.bitsPerRune)-1)
+	}
+	for _, option := range []struct {
+		set  bool
+		name string
+	}{
+		{enc.strictPad, "strict padding"},
+		{enc.recoverNFD, "NFD recovery"},
+		{enc.nonEmpty, "non-empty"},
+		{enc.trimSpace, "trailing space"},
+		{enc.marker, "marker"},
+		{enc.lengthGlyph, "length glyph"},
+		{enc.table != nil, "lookup table"},
+	} {
+		if option.set {
+			fmt.Fprintf(&b, ", %s", option.name)
+		}
+	}
+	if enc.maxInput > 0 {
+		fmt.Fprintf(&b, ", max input: %d", enc.maxInput)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// Dump renders a StdEncoding byte array as a table, see Encoding.Dump.
+func Dump(encoded []byte) string { return StdEncoding.Dump(encoded) }
+
+// Dump renders encoded as a human-readable table for debugging, e.g. to paste
+// into a bug report about a message that won't decode. There is one row per
+// rune, with its index, code point, value and lane for glyphs, and the data
+// bits for the padding symbol. A row for a rune that is neither is marked as
+// such, and the error that decoding stops at, if any, is appended.
+func (enc *Encoding) Dump(encoded []byte) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%5s  %-8s %-5s %-6s %-4s %s\n", "rune", "code", "glyph", "value", "lane", "bits")
+	for i, pos := 0, 0; pos < len(encoded); i++ {
+		r, size := utf8.DecodeRune(encoded[pos:])
+		pos += size
+		fmt.Fprintf(&b, "%5d  U+%04X   %-5q ", i, r, r)
+		if value, ok := enc.DecodeRune(r); ok {
+			bit := i * int(enc.bitsPerRune)
+			fmt.Fprintf(&b, "0x%04x %-4d %d-%d\n", value, value>>12, bit, bit+int(enc.bitsPerRune)-1)
+		} else if enc.isPadding(r) {
+			fmt.Fprintf(&b, "%-6s %-4s %d data bits in the last glyph\n", "pad", "", r-enc.paddingStart(r))
+		} else {
+			b.WriteString("not a glyph or padding symbol\n")
+		}
+	}
+	if _, err := enc.Decode(encoded); err != nil {
+		fmt.Fprintf(&b, "error: %s\n", err)
+	}
+	return b.String()
+}
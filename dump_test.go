@@ -0,0 +1,46 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEncodingString(t *testing.T) {
+	expected := map[*Encoding]string{
+		StdEncoding: "base32k.Encoding{bits: 15, lanes: U+8000 U+9000 U+4000 U+B000 U+C000 U+5000 U+6000 U+7000, padding: 'b'-'o'}",
+		SafeEncoding.WithPadding(NoPadding).StrictPadding(): "base32k.Encoding{bits: 14, lanes: U+5000 U+6000 U+7000 U+8000, padding: none, strict padding}",
+		BMPEncoding.WithMaxInputBytes(100):                  "base32k.Encoding{bits: 16, lanes: U+0100-U+108FF, padding: 'b'-'p', max input: 100}",
+	}
+	for enc, s := range expected {
+		if enc.String() != s {
+			t.Error(fmt.Sprintf("Expected %s, got %s", s, enc.String()))
+		}
+	}
+}
+
+func TestDump(t *testing.T) {
+	for n, encoded := range encodeExpectedBytes {
+		dump := Dump(encoded)
+		if rows := strings.Count(dump, "\n"); rows != len([]rune(string(encoded)))+1 {
+			t.Error(fmt.Sprintf("[%d] Dump has %d rows:\n%s", n, rows, dump))
+		}
+		if strings.Contains(dump, "error") || strings.Contains(dump, "not a glyph") {
+			t.Error(fmt.Sprintf("[%d] Dump of a valid encoding reports an error:\n%s", n, dump))
+		}
+	}
+	dump := Dump([]byte("缀x老j"))
+	if !strings.Contains(dump, "    1  U+0078   'x'   not a glyph or padding symbol\n") {
+		t.Error(fmt.Sprintf("Dump doesn't mark the invalid rune:\n%s", dump))
+	}
+	if !strings.Contains(dump, "    3  U+006A   'j'   pad         9 data bits in the last glyph\n") {
+		t.Error(fmt.Sprintf("Dump doesn't show the padding symbol:\n%s", dump))
+	}
+	if !strings.HasSuffix(dump, "error: Invalid character or misplaced padding character at position 1: x\n") {
+		t.Error(fmt.Sprintf("Dump doesn't end with the error:\n%s", dump))
+	}
+}
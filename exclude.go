@@ -0,0 +1,94 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+// A RuneRange is a range of code points from Lo to Hi, inclusive.
+type RuneRange struct {
+	Lo, Hi rune
+}
+
+// The escape sequence for an excluded glyph is made of two Hangul syllables
+// from U+D000 - U+D1FF, outside of the lanes: one of U+D000 - U+D07F for the
+// top 7 bits of the glyph value, followed by one of U+D100 - U+D1FF for its
+// low 8 bits.
+const escapeHigh, escapeLow = 0xd000, 0xd100
+
+// WithExclusions creates a new encoding identical to enc, except that it
+// avoids the glyphs in the given ranges, e.g. those that render as tofu in a
+// particular font. A glyph value that would map to an excluded glyph is
+// written as an escape sequence of two Hangul syllables from U+D000 - U+D1FF
+// instead, which the decoder reverses. The decoder also still accepts the
+// excluded glyphs themselves.
+//
+// Unlike SafeEncoding, this keeps 15 bits per glyph for all other values, but
+// the encoding becomes variable-length: every excluded value takes up two
+// glyphs, so EncodedLength and the other length functions only give the
+// minimum length. Each range excluded costs a linear scan per glyph when
+// encoding.
+//
+// The ranges must not overlap with the escape sequences, and BMPEncoding,
+// which has no room for them, doesn't support exclusions.
+func (enc Encoding) WithExclusions(ranges ...RuneRange) *Encoding {
+	if enc.bmp {
+		panic("exclusions not supported")
+	}
+	for _, excluded := range ranges {
+		if excluded.Lo > excluded.Hi || (excluded.Lo < escapeLow+0x100 && excluded.Hi >= escapeHigh) {
+			panic("invalid exclusion range")
+		}
+	}
+	enc.exclusions = append(enc.exclusions[:len(enc.exclusions):len(enc.exclusions)], ranges...)
+	return &enc
+}
+
+// isExcluded reports whether the glyph r is excluded from the encoding.
+func (enc *Encoding) isExcluded(r rune) bool {
+	for _, excluded := range enc.exclusions {
+		if r >= excluded.Lo && r <= excluded.Hi {
+			return true
+		}
+	}
+	return false
+}
+
+// isEscape reports whether r is part of an escape sequence of the encoding.
+func (enc *Encoding) isEscape(r rune) bool {
+	return len(enc.exclusions) > 0 &&
+		((r >= escapeHigh && r < escapeHigh+0x80) || (r >= escapeLow && r < escapeLow+0x100))
+}
+
+// countEscapes returns the number of escape sequences in s, each of which
+// takes up two runes for a single glyph value.
+func (enc *Encoding) countEscapes(s string) (count int) {
+	if len(enc.exclusions) == 0 {
+		return 0
+	}
+	for _, r := range s {
+		if enc.isEscape(r) && r >= escapeLow {
+			count += 1
+		}
+	}
+	return count
+}
+
+// decodeEscape decodes a rune of an escape sequence, see decodeRune.
+func (d *decoder) decodeEscape(i int, r rune) error {
+	if r < escapeLow {
+		if d.escape != 0 {
+			return CorruptInputError{d.escapeIndex, d.escape, "Incomplete escape sequence"}
+		}
+		d.escape, d.escapeIndex = r, i
+		return nil
+	}
+	if d.escape == 0 {
+		return CorruptInputError{i, r, "Escape sequence without start"}
+	}
+	value := uint16(d.escape-escapeHigh)<<8 | uint16(r-escapeLow)
+	d.escape = 0
+	if value >= 1<<d.enc.bitsPerRune {
+		return CorruptInputError{d.escapeIndex, r, "Invalid escape sequence"}
+	}
+	return d.writeValue(value)
+}
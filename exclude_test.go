@@ -0,0 +1,81 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Unassigned code points at the end of CJK Extension A and the CJK Unified
+// Ideographs, which many fonts render as tofu.
+var testExclusions = []RuneRange{{0x4db6, 0x4dbf}, {0x9fa6, 0x9fff}}
+
+// excludedData returns 15 bytes of data whose glyph values map to both ends of
+// the test exclusions, mixed with regular glyph values.
+func excludedData() []byte {
+	bits := bitWriter{width: BITS_PER_RUNE}
+	var data []byte
+	for _, value := range []uint16{0x0000, 0x2db6, 0x1fa6, 0x7fff, 0x1fff, 0x2dbf, 0x2db5, 0x1fa5} {
+		written, n := bits.write(value)
+		data = append(data, written[:n]...)
+	}
+	return data
+}
+
+func TestWithExclusions(t *testing.T) {
+	enc := StdEncoding.WithExclusions(testExclusions...)
+	for i, data := range [][]byte{excludedData(), excludedData()[:14], srcData} {
+		t.Run(fmt.Sprintf("data_%d", i), func(t *testing.T) {
+			encoded := enc.EncodeToString(data)
+			for _, r := range encoded {
+				if enc.isExcluded(r) {
+					t.Error(fmt.Sprintf("[%d] Excluded glyph %U in %s", i, r, encoded))
+				}
+			}
+			decoded, err := enc.DecodeFromString(encoded)
+			if err != nil || !bytes.Equal(decoded, data) {
+				t.Error(fmt.Sprintf("[%d] Round trip failed: %x (%v)", i, decoded, err))
+			}
+			// The excluded glyphs themselves still decode.
+			decoded, err = enc.DecodeFromString(StdEncoding.EncodeToString(data))
+			if err != nil || !bytes.Equal(decoded, data) {
+				t.Error(fmt.Sprintf("[%d] Plain decoding failed: %x (%v)", i, decoded, err))
+			}
+		})
+	}
+	if encoded := enc.EncodeToString(excludedData()); len([]rune(encoded)) != 8+4 {
+		t.Error(fmt.Sprintf("Unexpected escaped length: %s", encoded))
+	}
+	if encoded := StdEncoding.EncodeToString(excludedData()); !strings.ContainsRune(encoded, 0x9fa6) {
+		t.Error(fmt.Sprintf("Exclusions leaked into StdEncoding: %s", encoded))
+	}
+}
+
+func TestWithExclusionsErrors(t *testing.T) {
+	enc := StdEncoding.WithExclusions(testExclusions...)
+	for i, tc := range []struct {
+		src      string
+		position int
+		reason   string
+	}{
+		{"耀퀀", 1, "Incomplete escape sequence"},
+		{"퀀耀", 0, "Incomplete escape sequence"},
+		{"퀀퀁턀", 0, "Incomplete escape sequence"},
+		{"퀀b", 0, "Incomplete escape sequence"},
+		{"耀턀", 1, "Escape sequence without start"},
+		{"킀턀", 0, "Character U+D080 in lane 0xd, which the encoding doesn't use"},
+	} {
+		_, err := enc.DecodeFromString(tc.src)
+		if corrupt, ok := err.(CorruptInputError); !ok || corrupt.Position != tc.position || corrupt.Reason != tc.reason {
+			t.Error(fmt.Sprintf("[%d] Unexpected error for %q: %v", i, tc.src, err))
+		}
+	}
+	if _, err := StdEncoding.DecodeFromString("큿퇿"); err == nil {
+		t.Error("Escape sequence accepted without exclusions")
+	}
+}
@@ -0,0 +1,82 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidSeparator is returned by EncodeFields and DecodeFields if the
+// separator could be mistaken for a glyph or padding symbol of the encoding.
+var ErrInvalidSeparator = errors.New("Separator is part of the alphabet")
+
+// EncodeFields encodes each of blobs with StdEncoding and joins them with sep,
+// see Encoding.EncodeFields.
+func EncodeFields(blobs [][]byte, sep rune) (dest string, err error) {
+	return StdEncoding.EncodeFields(blobs, sep)
+}
+
+// DecodeFields decodes the StdEncoding fields of src separated by sep, see
+// Encoding.DecodeFields.
+func DecodeFields(src []byte, sep rune) (fields [][]byte, err error) {
+	return StdEncoding.DecodeFields(src, sep)
+}
+
+// DecodeFields splits src at every sep, e.g. ',' or ' ' in a delimited text
+// file, and decodes each field on its own. Empty fields decode to empty data.
+// The separator must be a valid rune that is neither a glyph nor a padding
+// symbol of the encoding, otherwise ErrInvalidSeparator is returned. The
+// Position of a CorruptInputError is the rune index in src, not in the field.
+func (enc *Encoding) DecodeFields(src []byte, sep rune) (fields [][]byte, err error) {
+	if !enc.validSeparator(sep) {
+		return nil, ErrInvalidSeparator
+	}
+	offset := 0
+	for _, field := range bytes.Split(src, []byte(string(sep))) {
+		decoded, err := enc.Decode(field)
+		if corrupt, ok := err.(CorruptInputError); ok {
+			corrupt.Position += offset
+			return nil, corrupt
+		} else if err != nil {
+			return nil, err
+		}
+		fields = append(fields, decoded)
+		offset += utf8.RuneCount(field) + 1
+	}
+	return fields, nil
+}
+
+// EncodeFields encodes each of blobs on its own, with its own padding symbol,
+// and joins the encodings with sep, e.g. to pack several small records into a
+// single tweet. DecodeFields splits and decodes the result again, except that
+// no blobs at all come back as a single empty one. The separator must be a
+// valid rune that is neither a glyph nor a padding symbol of the encoding,
+// otherwise ErrInvalidSeparator is returned.
+func (enc *Encoding) EncodeFields(blobs [][]byte, sep rune) (dest string, err error) {
+	if !enc.validSeparator(sep) {
+		return "", ErrInvalidSeparator
+	}
+	var destBuf strings.Builder
+	length := max(len(blobs)-1, 0) * utf8.RuneLen(sep)
+	for _, blob := range blobs {
+		length += enc.EncodedByteLength(len(blob))
+	}
+	destBuf.Grow(length)
+	for i, blob := range blobs {
+		if i > 0 {
+			destBuf.WriteRune(sep)
+		}
+		enc.encodeRunes(blob, func(r rune) { destBuf.WriteRune(r) })
+	}
+	return destBuf.String(), nil
+}
+
+// validSeparator reports whether sep can separate encodings of enc.
+func (enc *Encoding) validSeparator(sep rune) bool {
+	return utf8.ValidRune(sep) && !enc.isGlyph(sep) && !enc.isPadding(sep) && !enc.isEscape(sep)
+}
@@ -0,0 +1,84 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDecodeFields(t *testing.T) {
+	for _, sep := range []rune{',', ' ', '\t', '|', '　'} {
+		t.Run(fmt.Sprintf("separator_%U", sep), func(t *testing.T) {
+			var encoded []string
+			for n := range srcData {
+				encoded = append(encoded, encodeExpectedStrings[n%10])
+			}
+			fields, err := DecodeFields([]byte(strings.Join(encoded, string(sep))), sep)
+			if err != nil {
+				t.Fatal(fmt.Sprintf("Error while decoding: %s", err))
+			}
+			if len(fields) != len(encoded) {
+				t.Fatal(fmt.Sprintf("Decoded %d fields, expected %d", len(fields), len(encoded)))
+			}
+			for i, field := range fields {
+				if !bytes.Equal(field, srcData[:i%10]) {
+					t.Error(fmt.Sprintf("[%d] Field mismatch: %v", i, field))
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeFieldsInvalidSeparator(t *testing.T) {
+	for _, sep := range []rune{'b', 'o', '缀', 0x4000, 0xc5ff, -1, 0xd800} {
+		if _, err := DecodeFields([]byte("缀老j"), sep); err != ErrInvalidSeparator {
+			t.Error(fmt.Sprintf("%U: Expected ErrInvalidSeparator, got: %v", sep, err))
+		}
+	}
+}
+
+func TestDecodeFieldsPosition(t *testing.T) {
+	_, err := DecodeFields([]byte("缀老j,耀i,缀x老j"), ',')
+	var corrupt CorruptInputError
+	if !errors.As(err, &corrupt) || corrupt.Position != 8 {
+		t.Error(fmt.Sprintf("Expected CorruptInputError at position 8, got: %v", err))
+	}
+}
+
+func TestEncodeFields(t *testing.T) {
+	blobs := [][]byte{srcData[:1], {}, srcData[:15], srcData, srcData[:2], {}}
+	for _, sep := range []rune{',', ' ', '\n', '　', '😀'} {
+		t.Run(fmt.Sprintf("separator_%U", sep), func(t *testing.T) {
+			encoded, err := EncodeFields(blobs, sep)
+			if err != nil {
+				t.Fatal(fmt.Sprintf("Error while encoding: %s", err))
+			}
+			if strings.Count(encoded, string(sep)) != len(blobs)-1 {
+				t.Error(fmt.Sprintf("Expected %d separators in '%s'", len(blobs)-1, encoded))
+			}
+			fields, err := DecodeFields([]byte(encoded), sep)
+			if err != nil || len(fields) != len(blobs) {
+				t.Fatal(fmt.Sprintf("Decoded %d fields, expected %d (%v)", len(fields), len(blobs), err))
+			}
+			for i, field := range fields {
+				if !bytes.Equal(field, blobs[i]) {
+					t.Error(fmt.Sprintf("[%d] Field mismatch: %x", i, field))
+				}
+			}
+		})
+	}
+	if encoded, err := EncodeFields(nil, ','); err != nil || encoded != "" {
+		t.Error(fmt.Sprintf("Expected no fields to encode to nothing, got '%s' (%v)", encoded, err))
+	}
+	for _, sep := range []rune{'b', 'o', '缀', 0x4000, -1, 0xd800} {
+		if _, err := EncodeFields(blobs, sep); err != ErrInvalidSeparator {
+			t.Error(fmt.Sprintf("%U: Expected ErrInvalidSeparator, got: %v", sep, err))
+		}
+	}
+}
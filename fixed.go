@@ -0,0 +1,51 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// FILLER_SYMBOL is appended by EncodeFixed to fill up the encoding to the
+// requested number of glyphs. U+3000 IDEOGRAPHIC SPACE is outside of all lanes
+// and isn't a padding symbol, so it can't be mistaken for data, and it is as
+// wide as the CJK and Hangul glyphs in most fonts.
+const FILLER_SYMBOL = '\u3000'
+
+// ErrFixedWidth is returned by EncodeFixed if the encoded data doesn't fit
+// into the requested number of glyphs.
+var ErrFixedWidth = errors.New("Encoded data exceeds fixed width")
+
+// EncodeFixed encodes a given byte array of data with StdEncoding into a
+// string of exactly glyphs runes, see Encoding.EncodeFixed.
+func EncodeFixed(src []byte, glyphs int) (dest string, err error) {
+	return StdEncoding.EncodeFixed(src, glyphs)
+}
+
+// DecodeFixed decodes a string created by EncodeFixed, see
+// Encoding.DecodeFixed.
+func DecodeFixed(s string) (dest []byte, err error) { return StdEncoding.DecodeFixed(s) }
+
+// EncodeFixed encodes a given byte array of data into a base32k string of
+// exactly glyphs runes, for protocols with fixed-size fields. The encoding,
+// including its padding symbol, is filled up with FILLER_SYMBOL. If it needs
+// more than glyphs runes, ErrFixedWidth is returned.
+func (enc *Encoding) EncodeFixed(src []byte, glyphs int) (dest string, err error) {
+	encoded := enc.Encode(src)
+	fill := glyphs - utf8.RuneCount(encoded)
+	if fill < 0 {
+		return "", ErrFixedWidth
+	}
+	return string(encoded) + strings.Repeat(string(FILLER_SYMBOL), fill), nil
+}
+
+// DecodeFixed decodes a string created by EncodeFixed, by removing the
+// trailing FILLER_SYMBOLs and decoding the rest. A filler symbol anywhere else
+// is an invalid character.
+func (enc *Encoding) DecodeFixed(s string) (dest []byte, err error) {
+	return enc.DecodeFromString(strings.TrimRight(s, string(FILLER_SYMBOL)))
+}
@@ -0,0 +1,50 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEncodeFixed(t *testing.T) {
+	for _, glyphs := range []int{0, 1, 10, 11, 140} {
+		for n, expected := range encodeExpectedStrings {
+			t.Run(fmt.Sprintf("width_%d_data_size_%d", glyphs, n), func(t *testing.T) {
+				encoded, err := EncodeFixed(srcData[:n], glyphs)
+				if utf8.RuneCountInString(expected) > glyphs {
+					if err != ErrFixedWidth {
+						t.Error(fmt.Sprintf("[%d] Expected ErrFixedWidth, got: %v", n, err))
+					}
+					return
+				}
+				if err != nil {
+					t.Error(fmt.Sprintf("[%d] Error while encoding: %s", n, err))
+				}
+				if length := utf8.RuneCountInString(encoded); length != glyphs {
+					t.Error(fmt.Sprintf("[%d] Encoded to %d glyphs, expected %d", n, length, glyphs))
+				}
+				decoded, err := DecodeFixed(encoded)
+				if err != nil {
+					t.Error(fmt.Sprintf("[%d] Error while decoding: %s", n, err))
+				}
+				if !bytes.Equal(decoded, srcData[:n]) {
+					t.Error(fmt.Sprintf("[%d] Round trip mismatch: %v", n, decoded))
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeFixedMisplacedFiller(t *testing.T) {
+	_, err := DecodeFixed("缀　老j　")
+	var corrupt CorruptInputError
+	if !errors.As(err, &corrupt) || corrupt.Position != 1 {
+		t.Error(fmt.Sprintf("Expected CorruptInputError at position 1, got: %v", err))
+	}
+}
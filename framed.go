@@ -0,0 +1,89 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"encoding/binary"
+	"errors"
+	"unicode/utf8"
+)
+
+// ErrFrameLength is returned by DecodeFramed if the length prefix of the frame
+// is not a valid varint.
+var ErrFrameLength = errors.New("Invalid frame length")
+
+// EncodeFramed encodes a given byte array of data with StdEncoding into a
+// frame that carries its own length, see Encoding.EncodeFramed.
+func EncodeFramed(src []byte) (dest string) { return StdEncoding.EncodeFramed(src) }
+
+// DecodeFramed decodes a frame created by EncodeFramed, see
+// Encoding.DecodeFramed.
+func DecodeFramed(s string) (dest []byte, err error) { return StdEncoding.DecodeFramed(s) }
+
+// EncodeFramed encodes a given byte array of data into a base32k frame, for
+// transports that may alter or append to the end of the encoding, where the
+// padding symbol can't be relied on. The frame is the encoding, without a
+// padding symbol, of the length of src as a uvarint (see encoding/binary)
+// followed by src itself. There is no delimiter between the two, the uvarint
+// ends with the first byte below 0x80. This costs one byte for data up to 127
+// bytes and another byte for every further 7 bits of length.
+func (enc *Encoding) EncodeFramed(src []byte) (dest string) {
+	framed := make([]byte, 0, binary.MaxVarintLen64+len(src))
+	framed = binary.AppendUvarint(framed, uint64(len(src)))
+	unpadded := *enc
+	unpadded.padStart = NoPadding
+	return unpadded.EncodeToString(append(framed, src...))
+}
+
+// DecodeFramed decodes a base32k frame created by EncodeFramed. It decodes the
+// length prefix first, then only as many glyphs as needed for that many bytes
+// of data, and ignores the rest of s. A padding symbol within the frame is an
+// error, while ErrUnexpectedEnd is returned if s ends before the frame does.
+func (enc *Encoding) DecodeFramed(s string) (dest []byte, err error) {
+	if err = enc.checkInput(len(s)); err != nil {
+		return nil, err
+	}
+	d := newDecoder(enc)
+	var length uint64
+	prefix := 0
+	// complete reports whether the frame has been decoded.
+	complete := func() bool {
+		if prefix == 0 {
+			length, prefix = binary.Uvarint(d.out)
+			if prefix < 0 || (prefix == 0 && len(d.out) >= binary.MaxVarintLen64) {
+				err = ErrFrameLength
+				return false
+			}
+		}
+		return prefix > 0 && uint64(len(d.out)-prefix) >= length
+	}
+	for i, pos := 0, 0; pos < len(s); i++ {
+		r, size := utf8.DecodeRuneInString(s[pos:])
+		if r == utf8.RuneError && size == 1 {
+			return nil, invalidSequence(i, s[pos:])
+		}
+		pos += size
+		if err = d.decodeRune(i, r, false); err != nil {
+			return nil, err
+		}
+		if d.misplaced {
+			return nil, CorruptInputError{d.paddingIndex, d.padding, "Invalid character or misplaced padding character"}
+		}
+		if complete() {
+			return d.out[prefix : prefix+int(length)], nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if err = d.flushJamo(); err != nil {
+		return nil, err
+	}
+	if complete() {
+		return d.out[prefix : prefix+int(length)], nil
+	} else if err != nil {
+		return nil, err
+	}
+	return nil, ErrUnexpectedEnd
+}
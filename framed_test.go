@@ -0,0 +1,66 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEncodeFramed(t *testing.T) {
+	data := make([]byte, 1<<14+1)
+	rand.Read(data)
+	// Lengths around the sizes where the uvarint prefix grows.
+	for _, n := range []int{0, 1, 15, 16, 126, 127, 128, 129, 1<<14 - 1, 1 << 14, 1<<14 + 1} {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			for _, enc := range []*Encoding{StdEncoding, SafeEncoding, BMPEncoding} {
+				framed := enc.EncodeFramed(data[:n])
+				prefix := 1 + btoi(n >= 1<<7) + btoi(n >= 1<<14)
+				if expected, _ := enc.encodedRunes(prefix + n); utf8.RuneCountInString(framed) != expected {
+					t.Error(fmt.Sprintf("[%d] Framed to %d glyphs, expected %d", n, utf8.RuneCountInString(framed), expected))
+				}
+				for _, suffix := range []string{"", "\n", "i", "trailing text", "耀耀"} {
+					decoded, err := enc.DecodeFramed(framed + suffix)
+					if err != nil || !bytes.Equal(decoded, data[:n]) {
+						t.Error(fmt.Sprintf("[%d] Round trip failed with suffix %q: %v", n, suffix, err))
+					}
+				}
+			}
+		})
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestDecodeFramedErrors(t *testing.T) {
+	framed := EncodeFramed(srcData)
+	runes := []rune(framed)
+	if _, err := DecodeFramed(string(runes[:len(runes)-1])); err != ErrUnexpectedEnd {
+		t.Error("Expected ErrUnexpectedEnd for a truncated frame, got", err)
+	}
+	if _, err := DecodeFramed(""); err != ErrUnexpectedEnd {
+		t.Error("Expected ErrUnexpectedEnd for empty input, got", err)
+	}
+	if _, err := DecodeFramed(string(runes[:3]) + "i" + string(runes[3:])); err == nil {
+		t.Error("Padding symbol accepted within frame")
+	}
+	// A uvarint longer than 10 bytes, made up of 11 0xff bytes.
+	overlong := StdEncoding.EncodeToString(bytes.Repeat([]byte{0xff}, 15))
+	if _, err := DecodeFramed(overlong); err != ErrFrameLength {
+		t.Error("Expected ErrFrameLength, got", err)
+	}
+	if _, err := DecodeFramed("!" + strings.Repeat("耀", 3)); err == nil {
+		t.Error("Invalid character accepted within frame")
+	}
+}
@@ -0,0 +1,98 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "rewrite the golden files in testdata")
+
+// goldenCorpus generates the data encoded in the golden files: every length
+// from 0 to 64 bytes, which covers every bit offset of the final glyph, with
+// xorshift filler so that every lane is hit.
+func goldenCorpus() (corpus [][]byte) {
+	state := uint32(0x2545f491)
+	for n := 0; n <= 64; n += 1 {
+		data := make([]byte, n)
+		for i := range data {
+			state ^= state << 13
+			state ^= state >> 17
+			state ^= state << 5
+			data[i] = byte(state)
+		}
+		corpus = append(corpus, data)
+	}
+	return corpus
+}
+
+// writeGolden writes the encoding of the corpus to the golden file, one line
+// of hex data and its encoding per entry.
+func writeGolden(path string, enc *Encoding) error {
+	var b bytes.Buffer
+	for _, data := range goldenCorpus() {
+		fmt.Fprintf(&b, "%s %s\n", hex.EncodeToString(data), enc.EncodeToString(data))
+	}
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
+
+// checkGolden compares the encoding of the corpus to the golden file byte for
+// byte, and checks that the golden encodings decode back to the corpus.
+func checkGolden(t *testing.T, path string, enc *Encoding) {
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(fmt.Sprintf("Error reading golden file: %s", err))
+	}
+	lines := strings.Split(strings.TrimSuffix(string(golden), "\n"), "\n")
+	corpus := goldenCorpus()
+	if len(lines) != len(corpus) {
+		t.Fatal(fmt.Sprintf("Golden file has %d entries, expected %d", len(lines), len(corpus)))
+	}
+	for n, data := range corpus {
+		expected := hex.EncodeToString(data) + " " + enc.EncodeToString(data)
+		if lines[n] != expected {
+			t.Error(fmt.Sprintf("[%d] Encoding changed, expected '%s', got '%s'", n, lines[n], expected))
+			continue
+		}
+		_, encoded, _ := strings.Cut(lines[n], " ")
+		if decoded, err := enc.DecodeFromString(encoded); err != nil || !bytes.Equal(decoded, data) {
+			t.Error(fmt.Sprintf("[%d] Golden encoding decoded to %x (%v)", n, decoded, err))
+		}
+	}
+}
+
+func TestGolden(t *testing.T) {
+	encodings := map[string]*Encoding{"std": StdEncoding, "safe": SafeEncoding}
+	for name, enc := range encodings {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join("testdata", fmt.Sprintf("golden_%s.txt", name))
+			if *updateGolden {
+				if err := writeGolden(path, enc); err != nil {
+					t.Fatal(fmt.Sprintf("Error writing golden file: %s", err))
+				}
+			}
+			checkGolden(t, path, enc)
+		})
+	}
+	// The corpus must keep covering every lane.
+	var histogram [8]int
+	for _, data := range goldenCorpus() {
+		for lane, count := range LaneHistogram(data) {
+			histogram[lane] += count
+		}
+	}
+	for lane, count := range histogram {
+		if count == 0 {
+			t.Error(fmt.Sprintf("Golden corpus doesn't cover lane %d", lane))
+		}
+	}
+}
@@ -0,0 +1,53 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// Inspect decodes the StdEncoding byte array src without producing output and
+// reports its structure, see Encoding.Inspect.
+func Inspect(src []byte) (glyphs int, paddingDigit int, impliedBytes int, err error) {
+	return StdEncoding.Inspect(src)
+}
+
+// Inspect decodes src without producing output and reports its structure, for
+// finding out why a decoding comes out a byte short or long: the number of
+// data glyphs, the digit of the final padding symbol, i.e. the number of data
+// bits in the last glyph, or -1 if there is no padding symbol, and the length
+// of the data these imply. This is the decoding counterpart to EncodeStats.
+//
+// err is the error that decoding src would return. The other results are
+// still filled in as far as decoding got, and the padding digit is that of the
+// final rune if it's a padding symbol anywhere, so that a padding symbol that
+// doesn't fit the glyphs can be examined.
+func (enc *Encoding) Inspect(src []byte) (glyphs int, paddingDigit int, impliedBytes int, err error) {
+	if enc.trimSpace {
+		src = bytes.TrimRight(src, asciiSpace)
+	}
+	if err = enc.checkInput(len(src)); err != nil {
+		return 0, -1, 0, err
+	}
+	d := newDecoder(enc)
+	d.discard = true
+	err = d.decodeUTF8(src)
+	glyphs, paddingDigit = d.glyphs, -1
+	if last, _ := utf8.DecodeLastRune(src); enc.isPadding(last) {
+		paddingDigit = int(last - enc.paddingStart(last))
+	}
+	width := int(enc.bitsPerRune)
+	switch {
+	case enc.lengthGlyph:
+		impliedBytes, _, _ = enc.lengthOf(glyphs, d.length)
+	case paddingDigit >= 0 && glyphs > 0:
+		full := glyphs - 1
+		impliedBytes = full/BYTE_LEN*width + (full%BYTE_LEN*width+paddingDigit)/BYTE_LEN
+	default:
+		impliedBytes = enc.MaxDecodedLen(glyphs)
+	}
+	return glyphs, paddingDigit, impliedBytes, err
+}
@@ -0,0 +1,42 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestInspect(t *testing.T) {
+	for n, encoded := range encodeExpectedBytes {
+		glyphs, digit, implied, err := Inspect(encoded)
+		expectedGlyphs, expectedDigit := utf8.RuneCount(encoded), -1
+		if n%BITS_PER_RUNE != 0 {
+			expectedGlyphs, expectedDigit = expectedGlyphs-1, int(encoded[len(encoded)-1]-byte(PAD_START_SYMBOL))
+		}
+		if err != nil || glyphs != expectedGlyphs || digit != expectedDigit || implied != n {
+			t.Error(fmt.Sprintf("[%d] Expected %d glyphs, digit %d and %d bytes, got %d, %d and %d (%v)",
+				n, expectedGlyphs, expectedDigit, n, glyphs, digit, implied, err))
+		}
+	}
+	for _, enc := range []*Encoding{SafeEncoding, StdEncoding.WithPadding(NoPadding), StdEncoding.WithLengthGlyph()} {
+		for n := 1; n <= len(srcData); n += 1 {
+			decoded, decodeErr := enc.Decode(enc.Encode(srcData[:n]))
+			if _, _, implied, err := enc.Inspect(enc.Encode(srcData[:n])); err != decodeErr || decodeErr == nil && implied != len(decoded) {
+				t.Error(fmt.Sprintf("[%s/%d] Implied %d bytes, decoded %d (%v)", enc, n, implied, len(decoded), err))
+			}
+		}
+	}
+	// A padding symbol that doesn't fit is reported along with the error.
+	encoded := []rune(encodeExpectedStrings[4])
+	encoded[len(encoded)-1] = PAD_START_SYMBOL + 1
+	glyphs, digit, _, err := Inspect([]byte(string(encoded)))
+	var corrupt CorruptInputError
+	if !errors.As(err, &corrupt) || glyphs != 3 || digit != 1 {
+		t.Error(fmt.Sprintf("Expected 3 glyphs and digit 1 with an error, got %d and %d (%v)", glyphs, digit, err))
+	}
+}
@@ -0,0 +1,44 @@
+//go:build js && wasm
+
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import "syscall/js"
+
+// RegisterJS makes StdEncoding available to JavaScript as a global base32k
+// object, for WebAssembly builds running in a browser or Node.js:
+//
+//	base32k.encode(uint8array) // returns a string
+//	base32k.decode(string)     // returns a Uint8Array, or an Error
+//
+// decode returns an Error object instead of throwing, as a panicking Go
+// function would end the program. The functions stay callable as long as the
+// Go program runs, so main has to block after calling RegisterJS, see the
+// wasm command.
+func RegisterJS() {
+	object := js.Global().Get("Object").New()
+	object.Set("encode", js.FuncOf(func(this js.Value, args []js.Value) any {
+		if len(args) < 1 {
+			return js.Global().Get("Error").New("base32k.encode needs a Uint8Array")
+		}
+		src := make([]byte, args[0].Get("length").Int())
+		js.CopyBytesToGo(src, args[0])
+		return EncodeToString(src)
+	}))
+	object.Set("decode", js.FuncOf(func(this js.Value, args []js.Value) any {
+		if len(args) < 1 {
+			return js.Global().Get("Error").New("base32k.decode needs a string")
+		}
+		dest, err := DecodeFromString(args[0].String())
+		if err != nil {
+			return js.Global().Get("Error").New(err.Error())
+		}
+		array := js.Global().Get("Uint8Array").New(len(dest))
+		js.CopyBytesToJS(array, dest)
+		return array
+	}))
+	js.Global().Set("base32k", object)
+}
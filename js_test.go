@@ -0,0 +1,36 @@
+//go:build js && wasm
+
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"fmt"
+	"syscall/js"
+	"testing"
+)
+
+func TestRegisterJS(t *testing.T) {
+	RegisterJS()
+	object := js.Global().Get("base32k")
+	for n, expected := range encodeExpectedStrings {
+		array := js.Global().Get("Uint8Array").New(n)
+		js.CopyBytesToJS(array, srcData[:n])
+		encoded := object.Call("encode", array)
+		if encoded.String() != expected {
+			t.Error(fmt.Sprintf("[%d] Expected %s, got %s", n, expected, encoded.String()))
+		}
+		decoded := object.Call("decode", encoded)
+		data := make([]byte, decoded.Get("length").Int())
+		js.CopyBytesToGo(data, decoded)
+		if !bytes.Equal(data, srcData[:n]) {
+			t.Error(fmt.Sprintf("[%d] Expected %x, got %x", n, srcData[:n], data))
+		}
+	}
+	if result := object.Call("decode", "x"); !result.InstanceOf(js.Global().Get("Error")) {
+		t.Error(fmt.Sprintf("Expected an Error, got %v", result))
+	}
+}
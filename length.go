@@ -0,0 +1,87 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// ErrLengthGlyph is returned when the length glyph of an encoding created with
+// WithLengthGlyph doesn't fit the number of glyphs following it, or the bits
+// after the data aren't zero.
+var ErrLengthGlyph = errors.New("Length glyph inconsistent with the data")
+
+// WithLengthGlyph creates a new encoding identical to enc, except that it
+// keeps the whole encoding within the glyph alphabet: instead of the trailing
+// ASCII padding symbol, the glyphs are preceded by a length glyph whose value
+// is the length of the data modulo 15 (14 for SafeEncoding), i.e. the number
+// of bytes in the last, partial block. The last glyph is filled up with zero
+// bits, like the padding symbol's glyph, and the decoder drops the one zero
+// byte that this may add.
+//
+// This is for platforms that count ASCII and CJK characters differently, or
+// for implementations that don't support the padding symbols. The length
+// glyph costs a glyph for every non-empty input, where the padding symbol is
+// a single ASCII character in 14 out of 15 cases. The encoding has no padding,
+// a later WithPadding other than WithPadding(NoPadding) turns the length glyph
+// off again. As the length glyph precedes the data, a stream encoder from
+// NewEncoder holds all of the data in memory until it is closed.
+func (enc Encoding) WithLengthGlyph() *Encoding {
+	enc.lengthGlyph, enc.padStart = true, NoPadding
+	return &enc
+}
+
+// lengthOf returns the length of the data encoded by glyphs glyphs after a
+// length glyph of value rest, and the number of zero bits that fill up the
+// last glyph. The last block of the data has rest bytes, or is full if rest is
+// 0, so the glyphs consist of whole blocks of 8 glyphs and the partial glyphs
+// that rest bytes take up, which are 8 themselves for the longest rest.
+func (enc *Encoding) lengthOf(glyphs int, rest uint16) (length, unused int, ok bool) {
+	width := int(enc.bitsPerRune)
+	partial := (int(rest)*BYTE_LEN + width - 1) / width
+	if glyphs <= 0 || int(rest) >= width || glyphs < partial || (glyphs-partial)%BYTE_LEN != 0 {
+		return 0, 0, false
+	}
+	return (glyphs-partial)/BYTE_LEN*width + int(rest), partial*width - int(rest)*BYTE_LEN, true
+}
+
+// trimToLength checks the glyphs against the length glyph at the end of the
+// input and drops the zero byte that the last glyph may have added.
+func (d *decoder) trimToLength() error {
+	_, unused, ok := d.enc.lengthOf(d.glyphs, d.length)
+	if !ok {
+		return ErrLengthGlyph
+	}
+	// The bits after the data are the top bits of the last glyph.
+	if d.last>>(int(d.enc.bitsPerRune)-unused) != 0 && !d.replace {
+		return ErrLengthGlyph
+	}
+	if unused < BYTE_LEN || d.discard {
+		return nil
+	}
+	if len(d.overflow) > 0 {
+		d.overflow = d.overflow[:len(d.overflow)-1]
+	} else {
+		d.out = d.out[:len(d.out)-1]
+	}
+	return nil
+}
+
+// decodedLenOfLength is DecodedLenOfString for an encoding with a length
+// glyph, which is the first glyph of the count runes of s.
+func (enc *Encoding) decodedLenOfLength(s string, count int) (length int, err error) {
+	leader, size := utf8.DecodeRuneInString(s)
+	value, ok := enc.DecodeRune(leader)
+	if enc.isEscape(leader) && leader < escapeLow {
+		low, _ := utf8.DecodeRuneInString(s[size:])
+		value, ok = uint16(leader-escapeHigh)<<8|uint16(low-escapeLow), enc.isEscape(low) && low >= escapeLow
+	}
+	length, _, lengthOK := enc.lengthOf(count-1-enc.countEscapes(s), value)
+	if !ok || !lengthOK {
+		return 0, ErrLengthGlyph
+	}
+	return length, nil
+}
@@ -0,0 +1,97 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWithLengthGlyph(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, enc := range []*Encoding{
+		StdEncoding.WithLengthGlyph(),
+		SafeEncoding.WithLengthGlyph(),
+		BMPEncoding.WithLengthGlyph(),
+		StdEncoding.WithLengthGlyph().WithMarker(),
+		StdEncoding.WithLengthGlyph().WithExclusions(RuneRange{0x8000, 0x8003}),
+	} {
+		for length := 0; length < 50; length += 1 {
+			data := make([]byte, length)
+			rng.Read(data)
+			// Zero bytes at the end must not be mistaken for the fill bits.
+			if length%3 == 0 && length > 0 {
+				data[length-1] = 0
+			}
+			t.Run(fmt.Sprintf("%s_%d", enc, length), func(t *testing.T) {
+				encoded := enc.EncodeToString(data)
+				if strings.IndexFunc(encoded, func(r rune) bool { return r < utf8.RuneSelf }) >= 0 {
+					t.Error(fmt.Sprintf("[%d] ASCII in %s", length, encoded))
+				}
+				if count := utf8.RuneCountInString(encoded); len(enc.exclusions) == 0 && count != enc.EncodedLength(length) {
+					t.Error(fmt.Sprintf("[%d] EncodedLength %d, but %d runes", length, enc.EncodedLength(length), count))
+				}
+				decoded, err := enc.DecodeFromString(encoded)
+				if err != nil || !bytes.Equal(decoded, data) {
+					t.Error(fmt.Sprintf("[%d] Round trip failed: %x (%v)", length, decoded, err))
+				}
+				into := make([]byte, length)
+				if n, err := enc.DecodeInto(into, []byte(encoded)); err != nil || n != length || !bytes.Equal(into, data) {
+					t.Error(fmt.Sprintf("[%d] DecodeInto failed: %x (%v)", length, into[:n], err))
+				}
+				if err := enc.ValidEncoding([]byte(encoded)); err != nil {
+					t.Error(fmt.Sprintf("[%d] Invalid encoding: %v", length, err))
+				}
+				if decoded, err := io.ReadAll(NewDecoder(enc, strings.NewReader(encoded))); err != nil || !bytes.Equal(decoded, data) {
+					t.Error(fmt.Sprintf("[%d] Stream decoding failed: %x (%v)", length, decoded, err))
+				}
+				var streamed strings.Builder
+				encoder := NewEncoder(enc, &streamed)
+				encoder.Write(data[:length/2])
+				encoder.Write(data[length/2:])
+				if err := encoder.Close(); err != nil || streamed.String() != encoded {
+					t.Error(fmt.Sprintf("[%d] Unexpected stream encoding: %s (%v)", length, streamed.String(), err))
+				}
+				if length == 0 {
+					return
+				}
+				if n, err := enc.DecodedLenOfString(encoded); err != nil || n != length {
+					t.Error(fmt.Sprintf("[%d] Unexpected decoded length %d (%v)", length, n, err))
+				}
+			})
+		}
+	}
+}
+
+func TestWithLengthGlyphErrors(t *testing.T) {
+	enc := StdEncoding.WithLengthGlyph()
+	if enc.HasPadding() || !enc.WithPadding('A').HasPadding() || enc.WithPadding('A').lengthGlyph {
+		t.Error("The length glyph doesn't replace the padding")
+	}
+	runes := []rune(enc.EncodeToString(srcData[:4]))
+	for i, corrupt := range [][]rune{
+		runes[:1],                     // only the length glyph
+		runes[:len(runes)-1],          // a glyph missing
+		append(runes[:1:1], runes...), // a glyph too many
+		append([]rune{enc.EncodeRune(3)}, runes[1:]...),                       // a wrong length glyph
+		append([]rune{enc.EncodeRune(15)}, runes[1:]...),                      // an impossible length
+		append(runes[:len(runes)-1:len(runes)-1], runes[len(runes)-1]|0x0800), // fill bits set
+	} {
+		if _, err := enc.DecodeFromRunes(corrupt); err != ErrLengthGlyph {
+			t.Error(fmt.Sprintf("[%d] Expected ErrLengthGlyph, got: %v", i, err))
+		}
+		if err := enc.ValidEncoding([]byte(string(corrupt))); err != ErrLengthGlyph {
+			t.Error(fmt.Sprintf("[%d] Expected ErrLengthGlyph from ValidEncoding, got: %v", i, err))
+		}
+	}
+	if _, err := enc.DecodedLenOfString(string(runes[1:])); err != ErrLengthGlyph {
+		t.Error(fmt.Sprintf("Expected ErrLengthGlyph for a missing length glyph, got: %v", err))
+	}
+}
@@ -0,0 +1,79 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWithLookupTable(t *testing.T) {
+	data := make([]byte, 1000)
+	rand.New(rand.NewSource(1)).Read(data)
+	for _, enc := range []*Encoding{StdEncoding, SafeEncoding, BMPEncoding} {
+		table := enc.WithLookupTable()
+		for n := 0; n <= len(srcData); n += 1 {
+			if encoded := table.Encode(srcData[:n]); !bytes.Equal(encoded, enc.Encode(srcData[:n])) {
+				t.Error(fmt.Sprintf("[%d/%d] Unexpected encoding %s", enc.bitsPerRune, n, encoded))
+			}
+		}
+		if encoded := table.Encode(data); !bytes.Equal(encoded, enc.Encode(data)) {
+			t.Error(fmt.Sprintf("[%d] Unexpected encoding of random data", enc.bitsPerRune))
+		}
+		if appended := table.AppendEncodeString([]byte("x"), data); string(appended) != "x"+enc.EncodeToString(data) {
+			t.Error(fmt.Sprintf("[%d] Unexpected appended encoding of random data", enc.bitsPerRune))
+		}
+		lookup := table.lookupTable()
+		for value, glyph := range lookup.glyphs {
+			if r, size := utf8.DecodeRune(glyph[:]); r != enc.EncodeRune(uint16(value)) || size != int(lookup.sizes[value]) {
+				t.Error(fmt.Sprintf("[%d] Value 0x%x looks up %U of %d bytes", enc.bitsPerRune, value, r, lookup.sizes[value]))
+			}
+		}
+	}
+	// Encodings with exclusions and the prefixes are handled as well.
+	for _, enc := range []*Encoding{StdEncoding.WithExclusions(testExclusions...), StdEncoding.WithMarker().WithLengthGlyph()} {
+		if encoded := enc.WithLookupTable().Encode(data); !bytes.Equal(encoded, enc.Encode(data)) {
+			t.Error(fmt.Sprintf("[%s] Unexpected encoding of random data", enc))
+		}
+	}
+	// Appending into a buffer with exactly the room for the encoding, which
+	// the table copies mustn't overrun, allocates nothing.
+	for _, enc := range []*Encoding{StdEncoding.WithLookupTable(), SafeEncoding.WithLookupTable()} {
+		for n := 1; n <= len(srcData); n += 1 {
+			buf := make([]byte, 0, enc.EncodedByteLength(n))
+			if allocs := testing.AllocsPerRun(10, func() { enc.AppendEncodeString(buf, srcData[:n]) }); allocs != 0 {
+				t.Error(fmt.Sprintf("[%d/%d] Expected no allocations, got %v", enc.bitsPerRune, n, allocs))
+			}
+			if appended := enc.AppendEncodeString(buf, srcData[:n]); string(appended) != enc.EncodeToString(srcData[:n]) {
+				t.Error(fmt.Sprintf("[%d/%d] Unexpected appended encoding %s", enc.bitsPerRune, n, appended))
+			}
+		}
+	}
+	if StdEncoding.lookupTable() != nil || StdEncoding.WithLookupTable().Clone().table == nil {
+		t.Error("Unexpected lookup tables")
+	}
+}
+
+func BenchmarkEncodeLookupTable(b *testing.B) {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	for _, benchmark := range []struct {
+		name string
+		enc  *Encoding
+	}{{"computed", StdEncoding}, {"table", StdEncoding.WithLookupTable()}} {
+		b.Run(benchmark.name, func(b *testing.B) {
+			benchmark.enc.lookupTable() // builds the table
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i += 1 {
+				benchmark.enc.Encode(data)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import "unicode/utf8"
+
+// MARKER_SYMBOL precedes the glyphs of an encoding created with WithMarker.
+// U+3013 GETA MARK lies outside of all lanes of StdEncoding and isn't changed
+// by Unicode normalization, so it is never part of their data.
+const MARKER_SYMBOL = '〓'
+
+// WithMarker creates a new encoding identical to enc, except that it starts
+// every non-empty encoding with MARKER_SYMBOL, so that Detect can tell base32k
+// content apart from other text without decoding it. The decoders of all
+// encodings skip a leading marker, whether they add one or not, except for
+// BMPEncoding, for which MARKER_SYMBOL is also a glyph. The marker counts
+// towards the lengths returned by EncodedLength and friends.
+func (enc Encoding) WithMarker() *Encoding {
+	enc.marker = true
+	return &enc
+}
+
+// Detect reports whether src starts with MARKER_SYMBOL, i.e. is likely the
+// output of an encoding created with WithMarker. It doesn't check the rest of
+// src, which may still fail to decode. A byte order mark before the marker is
+// skipped, like the decoders do.
+func Detect(src []byte) bool {
+	r, size := utf8.DecodeRune(src)
+	if r == byteOrderMark {
+		r, _ = utf8.DecodeRune(src[size:])
+	}
+	return r == MARKER_SYMBOL
+}
+
+// isMarker reports whether r is the marker rather than a glyph. MARKER_SYMBOL
+// is also a glyph of BMPEncoding, which only reads it as the marker if it was
+// created with WithMarker.
+func (enc *Encoding) isMarker(r rune) bool { return r == MARKER_SYMBOL && (!enc.bmp || enc.marker) }
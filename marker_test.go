@@ -0,0 +1,83 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWithMarker(t *testing.T) {
+	enc := StdEncoding.WithMarker()
+	for i, expected := range encodeExpectedStrings {
+		t.Run(fmt.Sprintf("marked_%d", i), func(t *testing.T) {
+			data := srcData[:i]
+			encoded := enc.Encode(data)
+			if i == 0 {
+				if len(encoded) != 0 || Detect(encoded) {
+					t.Error(fmt.Sprintf("[%d] Marked empty data: %s", i, encoded))
+				}
+				return
+			}
+			if !Detect(encoded) || string(encoded) != string(MARKER_SYMBOL)+expected {
+				t.Error(fmt.Sprintf("[%d] Unexpected marked encoding: %s", i, encoded))
+			}
+			if length := enc.EncodedLength(i); length != utf8.RuneCount(encoded) {
+				t.Error(fmt.Sprintf("[%d] EncodedLength %d doesn't count the marker", i, length))
+			}
+			// Any decoder strips the marker, not only the marking one.
+			for _, decoder := range []*Encoding{enc, StdEncoding} {
+				decoded, err := decoder.Decode(encoded)
+				if err != nil || !bytes.Equal(decoded, data) {
+					t.Error(fmt.Sprintf("[%d] Round trip failed: %x (%v)", i, decoded, err))
+				}
+			}
+			if length, err := DecodedLenOfString(string(encoded)); err != nil || length != i {
+				t.Error(fmt.Sprintf("[%d] Unexpected decoded length %d (%v)", i, length, err))
+			}
+			var streamed strings.Builder
+			encoder := NewEncoder(enc, &streamed)
+			encoder.Write(data[:i/2])
+			encoder.Write(data[i/2:])
+			if err := encoder.Close(); err != nil || streamed.String() != string(encoded) {
+				t.Error(fmt.Sprintf("[%d] Unexpected stream encoding: %s (%v)", i, streamed.String(), err))
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for i, src := range []string{"", "plain text", encodeExpectedStrings[16], "x" + string(MARKER_SYMBOL)} {
+		if Detect([]byte(src)) {
+			t.Error(fmt.Sprintf("[%d] Unmarked content detected: %q", i, src))
+		}
+	}
+	if decoded, err := DecodeFromString(string(MARKER_SYMBOL)); err != nil || len(decoded) != 0 {
+		t.Error(fmt.Sprintf("Marker alone doesn't decode to empty data: %x (%v)", decoded, err))
+	}
+	// The marker is only skipped at the start.
+	if _, err := DecodeFromString(encodeExpectedStrings[1] + string(MARKER_SYMBOL)); err == nil {
+		t.Error("Misplaced marker decoded")
+	}
+}
+
+func TestMarkerGlyphBMP(t *testing.T) {
+	// U+3013 is a glyph of BMPEncoding, and only a leading one is the marker,
+	// and then only with WithMarker.
+	value, _ := BMPEncoding.DecodeRune(MARKER_SYMBOL)
+	data := []byte{byte(value), byte(value >> 8), 0x12, 0x34}
+	for _, enc := range []*Encoding{BMPEncoding, BMPEncoding.WithMarker()} {
+		encoded := enc.EncodeToString(data)
+		if decoded, err := enc.DecodeFromString(encoded); err != nil || !bytes.Equal(decoded, data) {
+			t.Error(fmt.Sprintf("[%s] Round trip of %q failed: %x (%v)", enc, encoded, decoded, err))
+		}
+		if length, err := enc.DecodedLenOfString(encoded); err != nil || length != len(data) {
+			t.Error(fmt.Sprintf("[%s] Unexpected decoded length %d (%v)", enc, length, err))
+		}
+	}
+}
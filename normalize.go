@@ -0,0 +1,117 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import "bytes"
+
+// Hangul syllables are composed algorithmically from a leading consonant (L),
+// a vowel (V) and an optional trailing consonant (T) jamo.
+// See: The Unicode Standard, Version 15.0, Section 3.12 "Conjoining Jamo
+// Behavior"
+const (
+	hangulBase = 0xac00
+	jamoLBase  = 0x1100
+	jamoVBase  = 0x1161
+	jamoTBase  = 0x11a7 // one before the first T, which stands for "no T"
+	jamoLCount = 19
+	jamoVCount = 21
+	jamoTCount = 28
+)
+
+// isJamo reports whether r is a conjoining Hangul jamo.
+func isJamo(r rune) bool { return r >= 0x1100 && r <= 0x11ff }
+
+// recomposeRune decodes r like decodeGlyph, but first reassembles Hangul
+// syllables from sequences of conjoining jamo.
+func (d *decoder) recomposeRune(i int, r rune, last bool) error {
+	if d.jamo >= hangulBase { // LV syllable, maybe followed by a T
+		if r > jamoTBase && r < jamoTBase+jamoTCount {
+			d.jamo += r - jamoTBase
+			return d.flushJamo()
+		}
+		if err := d.flushJamo(); err != nil {
+			return err
+		}
+	}
+	if d.jamo != 0 { // L, which must be followed by a V
+		if r < jamoVBase || r >= jamoVBase+jamoVCount {
+			return CorruptInputError{d.jamoIndex, d.jamo, "Incomplete decomposed Hangul syllable"}
+		}
+		d.jamo = hangulBase + ((d.jamo-jamoLBase)*jamoVCount+r-jamoVBase)*jamoTCount
+		return nil
+	}
+	if r >= jamoLBase && r < jamoLBase+jamoLCount {
+		d.jamo, d.jamoIndex = r, i
+		return nil
+	}
+	return d.decodeGlyph(i, r, last)
+}
+
+// flushJamo decodes the syllable recomposed so far, if any.
+func (d *decoder) flushJamo() error {
+	jamo := d.jamo
+	if jamo == 0 {
+		return nil
+	}
+	d.jamo = 0
+	if jamo < hangulBase {
+		return CorruptInputError{d.jamoIndex, jamo, "Incomplete decomposed Hangul syllable"}
+	}
+	return d.decodeGlyph(d.jamoIndex, jamo, false)
+}
+
+// homoglyphs maps the look-alikes that keyboards and autocorrect commonly put
+// in place of the ASCII padding symbols back to them. Fullwidth ASCII is mapped
+// separately, see unsubstitute.
+var homoglyphs = map[rune]rune{
+	'а': 'a', // U+0430 CYRILLIC SMALL LETTER A
+	'с': 'c', // U+0441 CYRILLIC SMALL LETTER ES
+	'ԁ': 'd', // U+0501 CYRILLIC SMALL LETTER KOMI DE
+	'е': 'e', // U+0435 CYRILLIC SMALL LETTER IE
+	'һ': 'h', // U+04BB CYRILLIC SMALL LETTER SHHA
+	'і': 'i', // U+0456 CYRILLIC SMALL LETTER BYELORUSSIAN-UKRAINIAN I
+	'ј': 'j', // U+0458 CYRILLIC SMALL LETTER JE
+	'о': 'o', // U+043E CYRILLIC SMALL LETTER O
+	'ο': 'o', // U+03BF GREEK SMALL LETTER OMICRON
+}
+
+// unsubstitute returns the original of a rune that may have been substituted
+// for an ASCII character, or r itself.
+func unsubstitute(r rune) rune {
+	if r >= 0xff01 && r <= 0xff5e { // FULLWIDTH EXCLAMATION MARK to TILDE
+		return r - 0xff01 + '!'
+	}
+	if original, ok := homoglyphs[r]; ok {
+		return original
+	}
+	return r
+}
+
+// DecodeNormalized decodes a StdEncoding byte array that may have been mangled
+// by autocorrect, see Encoding.DecodeNormalized.
+func DecodeNormalized(src []byte) (dest []byte, err error) {
+	return StdEncoding.DecodeNormalized(src)
+}
+
+// DecodeNormalized decodes a base32k byte array after mapping a small, fixed
+// set of character substitutions back to their originals, for recovering
+// messages that mobile keyboards or autocorrect have mangled. The glyphs
+// themselves are rarely substituted, but the ASCII padding symbol at the end
+// often is: its fullwidth form (e.g. "ｂ" for "b") and a few Cyrillic and Greek
+// look-alikes (e.g. "е" for "e") are recognized.
+//
+// Glyphs of enc are never mapped, so valid encodings decode the same as with
+// Decode, just a bit slower. For BMPEncoding, whose glyphs include all of the
+// substitutes, this means that nothing is recovered. Runes are mapped one to
+// one, so the Position of a CorruptInputError still refers to the original
+// input.
+func (enc *Encoding) DecodeNormalized(src []byte) (dest []byte, err error) {
+	return enc.Decode(bytes.Map(func(r rune) rune {
+		if enc.isGlyph(r) {
+			return r
+		}
+		return unsubstitute(r)
+	}, src))
+}
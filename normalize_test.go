@@ -0,0 +1,121 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// nfd decomposes the Hangul syllables in s into conjoining jamo, which is all
+// that NFD normalization changes in base32k strings.
+func nfd(s string) string {
+	var decomposed strings.Builder
+	for _, r := range s {
+		if r < hangulBase || r >= hangulBase+jamoLCount*jamoVCount*jamoTCount {
+			decomposed.WriteRune(r)
+			continue
+		}
+		index := r - hangulBase
+		decomposed.WriteRune(jamoLBase + index/(jamoVCount*jamoTCount))
+		decomposed.WriteRune(jamoVBase + index%(jamoVCount*jamoTCount)/jamoTCount)
+		if t := index % jamoTCount; t != 0 {
+			decomposed.WriteRune(jamoTBase + t)
+		}
+	}
+	return decomposed.String()
+}
+
+func TestDecodeNFD(t *testing.T) {
+	// The fixtures only contain CJK glyphs and are thus unchanged by NFD.
+	for n, encoded := range encodeExpectedStrings {
+		decoded, err := DecodeFromString(nfd(encoded))
+		if err != nil || !bytes.Equal(decoded, srcData[:n]) {
+			t.Error(fmt.Sprintf("[%d] Expected %x, got %x (%v)", n, srcData[:n], decoded, err))
+		}
+	}
+	recovering := StdEncoding.WithNFDRecovery()
+	rng := rand.New(rand.NewSource(1))
+	for length := 1; length < 200; length += 1 {
+		t.Run(fmt.Sprintf("length_%d", length), func(t *testing.T) {
+			data := make([]byte, length)
+			rng.Read(data)
+			encoded := EncodeToString(data)
+			normalized := nfd(encoded)
+			if normalized == encoded {
+				return
+			}
+			// Without recovery, decoding fails at the first decomposed glyph.
+			hangul := strings.IndexFunc(encoded, func(r rune) bool { return r >= 0xb000 && r <= 0xcfff })
+			_, err := DecodeFromString(normalized)
+			var corrupt CorruptInputError
+			if !errors.As(err, &corrupt) {
+				t.Error(fmt.Sprintf("[%d] Expected CorruptInputError, got: %v", length, err))
+			} else if expected := len([]rune(encoded[:hangul])); corrupt.Position != expected {
+				t.Error(fmt.Sprintf("[%d] Expected error at position %d, got: %d", length, expected, corrupt.Position))
+			}
+			decoded, err := recovering.DecodeFromString(normalized)
+			if err != nil || !bytes.Equal(decoded, data) {
+				t.Error(fmt.Sprintf("[%d] Expected %x, got %x (%v)", length, data, decoded, err))
+			}
+			decoded, err = io.ReadAll(NewDecoder(recovering, strings.NewReader(normalized)))
+			if err != nil || !bytes.Equal(decoded, data) {
+				t.Error(fmt.Sprintf("[%d] Expected %x from stream decoder, got %x (%v)", length, data, decoded, err))
+			}
+		})
+	}
+	// A lone leading consonant can't be recomposed.
+	if _, err := recovering.DecodeFromString("缀ᄀ"); err == nil {
+		t.Error("Expected an error for an incomplete syllable")
+	}
+}
+
+func TestDecodeNormalized(t *testing.T) {
+	// The padding symbols of the fixtures of 1, 2, 3, 4 and 8 bytes.
+	for i, substitution := range []struct {
+		padding, substitute string
+		n                   int
+	}{
+		{"i", "ｉ", 1}, {"b", "ｂ", 2}, {"j", "ｊ", 3}, {"i", "і", 1},
+		{"c", "с", 4}, {"e", "е", 8}, {"e", "ｅ", 8},
+	} {
+		encoded := encodeExpectedStrings[substitution.n]
+		if !strings.HasSuffix(encoded, substitution.padding) {
+			t.Fatal(fmt.Sprintf("[%d] Fixture %s doesn't end with %s", i, encoded, substitution.padding))
+		}
+		mangled := strings.TrimSuffix(encoded, substitution.padding) + substitution.substitute
+		if _, err := DecodeFromString(mangled); err == nil {
+			t.Error(fmt.Sprintf("[%d] Mangled fixture %s decoded without normalization", i, mangled))
+		}
+		decoded, err := DecodeNormalized([]byte(mangled))
+		if err != nil || !bytes.Equal(decoded, srcData[:substitution.n]) {
+			t.Error(fmt.Sprintf("[%d] Expected %x, got %x (%v)", i, srcData[:substitution.n], decoded, err))
+		}
+	}
+	for n, encoded := range encodeExpectedStrings {
+		decoded, err := DecodeNormalized([]byte(encoded))
+		if err != nil || !bytes.Equal(decoded, srcData[:n]) {
+			t.Error(fmt.Sprintf("[%d] Expected %x, got %x (%v)", n, srcData[:n], decoded, err))
+		}
+	}
+	// Glyphs of BMPEncoding that are also substitutes are left alone.
+	for value := 0; value < 1<<16; value += 1 {
+		data := []byte{byte(value), byte(value >> 8)}
+		encoded := BMPEncoding.Encode(data)
+		if decoded, err := BMPEncoding.DecodeNormalized(encoded); err != nil || !bytes.Equal(decoded, data) {
+			t.Fatal(fmt.Sprintf("[0x%04x] Expected %x from %q, got %x (%v)", value, data, encoded, decoded, err))
+		}
+	}
+	// Positions refer to the mangled input.
+	var corrupt CorruptInputError
+	if _, err := DecodeNormalized([]byte("ｚ" + encodeExpectedStrings[2])); !errors.As(err, &corrupt) || corrupt.Position != 0 {
+		t.Error(fmt.Sprintf("Expected CorruptInputError at 0, got: %v", err))
+	}
+}
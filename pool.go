@@ -0,0 +1,74 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBuffer is the largest capacity of a buffer that is returned to the
+// pool, so that a single large message doesn't pin its buffer forever.
+const maxPooledBuffer = 1 << 16
+
+// A pooledBuffer is what decodeBuffers holds: the buffer and a release
+// function bound to it, which is created along with it so that handing it out
+// doesn't allocate.
+type pooledBuffer struct {
+	data    []byte
+	release func()
+}
+
+var decodeBuffers sync.Pool
+
+// DecodePooled decodes a given StdEncoding byte array into a pooled buffer,
+// see Encoding.DecodePooled.
+func DecodePooled(src []byte) (dest []byte, release func(), err error) {
+	return StdEncoding.DecodePooled(src)
+}
+
+// DecodePooled decodes a given base32k byte array like Decode, but into a
+// buffer taken from a pool shared by all encodings, which amortizes the
+// allocation of the result over many calls, e.g. in a server decoding many
+// short messages concurrently.
+//
+// The caller must call release once it is done with dest, which returns the
+// buffer to the pool: dest must not be used, not even read, after that, as
+// the next call may already overwrite it. For the same reason release must be
+// called at most once. On an error, dest is nil and release does nothing.
+func (enc *Encoding) DecodePooled(src []byte) (dest []byte, release func(), err error) {
+	if enc.trimSpace {
+		src = bytes.TrimRight(src, asciiSpace)
+	}
+	if err = enc.checkInput(len(src)); err != nil || len(src) == 0 {
+		return nil, releaseNothing, err
+	}
+	buffer, _ := decodeBuffers.Get().(*pooledBuffer)
+	if buffer == nil {
+		buffer = new(pooledBuffer)
+		buffer.release = func() { putDecodeBuffer(buffer) }
+	}
+	d := newDecoder(enc)
+	d.out = buffer.data[:0]
+	err = d.decodeUTF8(src)
+	// Keep what the decoder may have grown the buffer to.
+	buffer.data = d.out
+	if err != nil {
+		putDecodeBuffer(buffer)
+		return nil, releaseNothing, err
+	}
+	return buffer.data, buffer.release, nil
+}
+
+func releaseNothing() {}
+
+// putDecodeBuffer returns buffer to the pool, unless it has grown too large.
+func putDecodeBuffer(buffer *pooledBuffer) {
+	if cap(buffer.data) > maxPooledBuffer {
+		return
+	}
+	buffer.data = buffer.data[:0]
+	decodeBuffers.Put(buffer)
+}
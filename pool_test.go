@@ -0,0 +1,76 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestDecodePooled(t *testing.T) {
+	for n, encoded := range encodeExpectedBytes {
+		decoded, release, err := DecodePooled(encoded)
+		if err != nil || !bytes.Equal(decoded, srcData[:n]) {
+			t.Error(fmt.Sprintf("[%d] Expected %x, got %x (%v)", n, srcData[:n], decoded, err))
+		}
+		release()
+	}
+	for i, invalid := range [][]byte{[]byte("a"), encodeExpectedBytes[16][:4], []byte("缀縁x")} {
+		decoded, release, err := DecodePooled(invalid)
+		if err == nil || decoded != nil {
+			t.Error(fmt.Sprintf("[%d] Expected an error, got %x", i, decoded))
+		}
+		release()
+	}
+	// Buffers are reused without leaking old data into the next result.
+	rng := rand.New(rand.NewSource(1))
+	for length := 200; length > 0; length -= 7 {
+		data := make([]byte, length)
+		rng.Read(data)
+		decoded, release, err := DecodePooled(Encode(data))
+		if err != nil || !bytes.Equal(decoded, data) {
+			t.Error(fmt.Sprintf("[%d] Expected %x, got %x (%v)", length, data, decoded, err))
+		}
+		release()
+	}
+}
+
+func TestDecodePooledAllocs(t *testing.T) {
+	// Once the pool holds a buffer, decoding into it allocates nothing.
+	encoded := encodeExpectedBytes[16]
+	allocs := testing.AllocsPerRun(100, func() {
+		_, release, _ := DecodePooled(encoded)
+		release()
+	})
+	if allocs != 0 {
+		t.Error(fmt.Sprintf("Expected no allocations, got %v", allocs))
+	}
+}
+
+func BenchmarkDecodePooled(b *testing.B) {
+	// A tweet's worth of data, as decoded by a busy server.
+	data := make([]byte, 260)
+	rand.New(rand.NewSource(1)).Read(data)
+	encoded := Encode(data)
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, release, _ := DecodePooled(encoded)
+				release()
+			}
+		})
+	})
+	b.Run("plain", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				Decode(encoded)
+			}
+		})
+	})
+}
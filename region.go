@@ -0,0 +1,97 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// ErrRegion is returned by DecodeRegion if the region doesn't lie within the
+// glyphs of the input.
+var ErrRegion = errors.New("Region out of range")
+
+// ErrRegionUnsupported is returned by DecodeRegion for encodings and input in
+// which glyph indexes aren't rune indexes.
+var ErrRegionUnsupported = errors.New("Region decoding not supported")
+
+// DecodeRegion decodes a glyph-aligned region of a StdEncoding encoding, see
+// Encoding.DecodeRegion.
+func DecodeRegion(src []byte, startGlyph, glyphCount int) (dest []byte, err error) {
+	return StdEncoding.DecodeRegion(src, startGlyph, glyphCount)
+}
+
+// DecodeRegion decodes only the glyphCount glyphs starting at glyph index
+// startGlyph of the base32k byte array src, e.g. a record in a large file of
+// concatenated records with an external index.
+//
+// A region starts and ends on glyph boundaries, which don't generally fall on
+// byte boundaries. The result holds only the bytes whose bits all lie within
+// the region, i.e. the data from bit startGlyph*15 rounded up to the next
+// whole byte, to bit (startGlyph+glyphCount)*15 rounded down. A record stored
+// at an arbitrary bit offset can therefore not be decoded on its own: it must
+// start and end on a whole byte, such as every 8 glyphs. If the region ends
+// with the last glyph, the padding symbol after it is taken into account.
+//
+// Glyph indices are rune indices into src, so anything in src other than
+// glyphs and the padding symbol would misalign the region: encodings created
+// with WithMarker, WithLengthGlyph or WithExclusions, and input starting with
+// a byte order mark or the marker, are rejected with ErrRegionUnsupported.
+// NFD-decomposed input is not supported either.
+func (enc *Encoding) DecodeRegion(src []byte, startGlyph, glyphCount int) (dest []byte, err error) {
+	if enc.marker || enc.lengthGlyph || len(enc.exclusions) > 0 {
+		return nil, ErrRegionUnsupported
+	}
+	if r, _ := utf8.DecodeRune(src); enc.isByteOrderMark(r) || enc.isMarker(r) {
+		return nil, ErrRegionUnsupported
+	}
+	if startGlyph < 0 || glyphCount < 0 {
+		return nil, ErrRegion
+	}
+	pos := 0
+	for i := 0; i < startGlyph; i++ {
+		if pos >= len(src) {
+			return nil, ErrRegion
+		}
+		_, size := utf8.DecodeRune(src[pos:])
+		pos += size
+	}
+	d := newDecoder(enc)
+	// A region is in the middle of the glyphs, where no marker is skipped.
+	d.started = true
+	// Start with the bits of the glyphs before the region carried over, so
+	// that the region's bits end up at their place in the decoded bytes.
+	firstBit := uint(startGlyph) * enc.bitsPerRune % BYTE_LEN
+	d.bits.bit = firstBit
+	i := startGlyph
+	for ; i < startGlyph+glyphCount; i++ {
+		if pos >= len(src) {
+			return nil, ErrRegion
+		}
+		r, size := utf8.DecodeRune(src[pos:])
+		if r == utf8.RuneError && size == 1 {
+			return nil, invalidSequence(i, src[pos:])
+		}
+		pos += size
+		if i == startGlyph && enc.isPadding(r) {
+			return nil, ErrRegion // not a glyph
+		}
+		if err = d.decodeRune(i, r, pos == len(src)); err != nil {
+			return nil, err
+		}
+	}
+	if r, size := utf8.DecodeRune(src[pos:]); glyphCount > 0 && pos+size == len(src) && enc.isPadding(r) {
+		if err = d.decodeRune(i, r, true); err != nil {
+			return nil, err
+		}
+	}
+	if err = d.flushJamo(); err != nil {
+		return nil, err
+	}
+	if firstBit != 0 && len(d.out) > 0 {
+		d.out = d.out[1:] // the byte shared with the glyph before the region
+	}
+	return d.out, nil
+}
@@ -0,0 +1,73 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestDecodeRegion(t *testing.T) {
+	for _, n := range []int{15, 16, 100} {
+		data := make([]byte, n)
+		rand.Read(data)
+		encoded := Encode(data)
+		glyphs := EncodedLength(n)
+		if n%BYTES_PER_RUNE != 0 {
+			glyphs -= 1 // padding symbol
+		}
+		for start := 0; start <= glyphs; start += 1 {
+			for count := 0; start+count <= glyphs; count += 1 {
+				t.Run(fmt.Sprintf("data_size_%d_glyphs_%d_%d", n, start, count), func(t *testing.T) {
+					// The bytes whose bits lie within the region.
+					first := (start*BITS_PER_RUNE + BYTE_LEN - 1) / BYTE_LEN
+					end := min((start+count)*BITS_PER_RUNE/BYTE_LEN, n)
+					expected := []byte{}
+					if first < end {
+						expected = data[first:end]
+					}
+					decoded, err := DecodeRegion(encoded, start, count)
+					if err != nil {
+						t.Fatal(fmt.Sprintf("[%d] Error while decoding: %s", n, err))
+					}
+					if !bytes.Equal(decoded, expected) {
+						t.Error(fmt.Sprintf("[%d] Expected %x, got %x", n, expected, decoded))
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestDecodeRegionOutOfRange(t *testing.T) {
+	encoded := Encode(srcData)
+	for _, region := range [][2]int{{-1, 1}, {0, -1}, {0, 11}, {9, 1}, {11, 0}} {
+		if _, err := DecodeRegion(encoded, region[0], region[1]); err != ErrRegion {
+			t.Error(fmt.Sprintf("%v: Expected ErrRegion, got: %v", region, err))
+		}
+	}
+}
+
+func TestDecodeRegionUnsupported(t *testing.T) {
+	encoded := Encode(srcData)
+	for _, enc := range []*Encoding{StdEncoding.WithMarker(), StdEncoding.WithLengthGlyph(), StdEncoding.WithExclusions(testExclusions...)} {
+		if _, err := enc.DecodeRegion(enc.Encode(srcData), 1, 2); err != ErrRegionUnsupported {
+			t.Error(fmt.Sprintf("[%s] Expected ErrRegionUnsupported, got: %v", enc, err))
+		}
+	}
+	for _, prefix := range []string{"\ufeff", string(MARKER_SYMBOL), "\ufeff" + string(MARKER_SYMBOL)} {
+		if _, err := DecodeRegion(append([]byte(prefix), encoded...), 1, 2); err != ErrRegionUnsupported {
+			t.Error(fmt.Sprintf("[%q] Expected ErrRegionUnsupported, got: %v", prefix, err))
+		}
+	}
+	// A marker within the glyphs is an invalid character, not skipped.
+	runes := []rune(string(encoded))
+	runes[2] = MARKER_SYMBOL
+	if _, err := DecodeRegion([]byte(string(runes)), 2, 3); err == nil {
+		t.Error("Expected an error for a marker within the region")
+	}
+}
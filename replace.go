@@ -0,0 +1,42 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+// DecodeReplacing decodes a damaged StdEncoding byte array as far as
+// possible, see Encoding.DecodeReplacing.
+func DecodeReplacing(src []byte, replacement byte) (dest []byte, substitutions int, err error) {
+	return StdEncoding.DecodeReplacing(src, replacement)
+}
+
+// DecodeReplacing decodes a damaged base32k byte array, for lossy recovery of
+// what is left of a message. Every rune that is not a glyph, except for a
+// padding symbol at the end, is decoded as if it were a glyph whose bits are
+// all those of the replacement byte, and decoding continues with the next
+// rune. Each invalid byte of UTF-8 counts as a rune. The number of replaced
+// runes is returned in substitutions.
+//
+// A glyph doesn't cover whole bytes, so the bytes straddling the boundaries
+// of a replaced glyph mix its replacement bits with the bits of the
+// neighboring glyphs, i.e. a single corrupt glyph may corrupt up to three
+// bytes, only one of which is the replacement byte. The following glyphs
+// still decode to aligned bytes, as long as no glyphs are missing altogether:
+// that can't be told apart from valid data and is only detected at the end, if
+// at all, where the usual errors are returned along with the decoded data.
+func (enc *Encoding) DecodeReplacing(src []byte, replacement byte) (dest []byte, substitutions int, err error) {
+	d := newDecoder(enc)
+	d.replace, d.replacement = true, replacement
+	err = d.decodeUTF8(src)
+	return d.out, d.substitutions, err
+}
+
+// substitute decodes a glyph made of replacement bits in place of an invalid
+// rune. The bits are rotated to line up with the byte boundaries, so that any
+// byte the glyph covers entirely becomes the replacement byte.
+func (d *decoder) substitute() error {
+	d.substitutions += 1
+	pattern := uint32(d.replacement) * 0x010101
+	value := uint16(pattern>>d.bits.bit) & (1<<d.enc.bitsPerRune - 1)
+	return d.writeValue(value)
+}
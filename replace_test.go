@@ -0,0 +1,52 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestDecodeReplacing(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			decoded, substitutions, err := DecodeReplacing([]byte(encoded), '?')
+			if err != nil || substitutions != 0 || !bytes.Equal(decoded, srcData[:n]) {
+				t.Error(fmt.Sprintf("[%d] Valid input decoded to %x, %d, %v", n, decoded, substitutions, err))
+			}
+		})
+	}
+	// Replace each glyph in turn, only the bytes it touches may change.
+	runes := []rune(encodeExpectedStrings[16])
+	for g := 0; g < len(runes)-1; g += 1 {
+		t.Run(fmt.Sprintf("glyph_%d", g), func(t *testing.T) {
+			damaged := append([]rune{}, runes...)
+			damaged[g] = '\ufffd'
+			decoded, substitutions, err := DecodeReplacing([]byte(string(damaged)), 0x3c)
+			if err != nil || substitutions != 1 {
+				t.Fatal(fmt.Sprintf("[%d] Decoded with %d substitutions, %v", g, substitutions, err))
+			}
+			if len(decoded) != len(srcData) {
+				t.Fatal(fmt.Sprintf("[%d] Decoded to %d bytes", g, len(decoded)))
+			}
+			first, end := g*BITS_PER_RUNE/BYTE_LEN, ((g+1)*BITS_PER_RUNE+BYTE_LEN-1)/BYTE_LEN
+			for i := range decoded {
+				covered := i*BYTE_LEN >= g*BITS_PER_RUNE && (i+1)*BYTE_LEN <= (g+1)*BITS_PER_RUNE
+				if covered && decoded[i] != 0x3c {
+					t.Error(fmt.Sprintf("[%d] Byte %d is 0x%02x, not the replacement", g, i, decoded[i]))
+				} else if (i < first || i >= end) && decoded[i] != srcData[i] {
+					t.Error(fmt.Sprintf("[%d] Byte %d outside of the glyph changed", g, i))
+				}
+			}
+		})
+	}
+	// Decoding continues after invalid runes of all kinds.
+	damaged := []byte(string(runes[:2]) + "x" + string(runes[3:5]) + "\xff" + string(runes[6:]))
+	decoded, substitutions, err := DecodeReplacing(damaged, 0)
+	if err != nil || substitutions != 2 || len(decoded) != len(srcData) || !bytes.Equal(decoded[12:], srcData[12:]) {
+		t.Error(fmt.Sprintf("Decoding didn't continue: %x, %d, %v", decoded, substitutions, err))
+	}
+}
@@ -0,0 +1,33 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import "io"
+
+// An EncodedResult is the UTF-8 encoding of some data, as returned by Encode,
+// that can be written to an io.Writer without copying it, e.g. in an HTTP
+// handler, or used as a string.
+type EncodedResult []byte
+
+// EncodeResult encodes a given byte array of data with StdEncoding into an
+// EncodedResult, see Encoding.EncodeResult.
+func EncodeResult(src []byte) EncodedResult { return StdEncoding.EncodeResult(src) }
+
+// EncodeResult encodes a given byte array of data like Encode, but returns it
+// as an EncodedResult.
+func (enc *Encoding) EncodeResult(src []byte) EncodedResult { return enc.Encode(src) }
+
+// WriteTo writes the encoding to w in a single write, implementing
+// io.WriterTo.
+func (result EncodedResult) WriteTo(w io.Writer) (n int64, err error) {
+	written, err := w.Write(result)
+	if err == nil && written < len(result) {
+		err = io.ErrShortWrite
+	}
+	return int64(written), err
+}
+
+// String returns the encoding as a string, implementing fmt.Stringer.
+func (result EncodedResult) String() string { return string(result) }
@@ -0,0 +1,39 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// shortWriter accepts one byte less than it is given, without an error.
+type shortWriter struct{}
+
+func (shortWriter) Write(p []byte) (int, error) { return max(len(p)-1, 0), nil }
+
+func TestEncodedResult(t *testing.T) {
+	var _ io.WriterTo = EncodedResult(nil)
+	var _ fmt.Stringer = EncodedResult(nil)
+	for n, expected := range encodeExpectedStrings {
+		result := EncodeResult(srcData[:n])
+		var buffer bytes.Buffer
+		written, err := result.WriteTo(&buffer)
+		if err != nil || written != int64(len(expected)) || buffer.String() != expected {
+			t.Error(fmt.Sprintf("[%d] Wrote %d bytes %q (%v)", n, written, buffer.String(), err))
+		}
+		if result.String() != expected || fmt.Sprint(result) != expected {
+			t.Error(fmt.Sprintf("[%d] Unexpected string %s", n, result))
+		}
+	}
+	if _, err := EncodeResult(srcData).WriteTo(shortWriter{}); err != io.ErrShortWrite {
+		t.Error(fmt.Sprintf("Expected io.ErrShortWrite, got: %v", err))
+	}
+	if _, err := EncodeResult(srcData).WriteTo(errorWriter{}); err == nil {
+		t.Error("Expected the error of the writer")
+	}
+}
@@ -0,0 +1,82 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// EncodeToStringSep encodes a given byte array of data with StdEncoding into a
+// base32k string with sep between the glyphs, see Encoding.EncodeToStringSep.
+func EncodeToStringSep(src []byte, sep string) (dest string, err error) {
+	return StdEncoding.EncodeToStringSep(src, sep)
+}
+
+// DecodeFromStringSep decodes a StdEncoding string with separators between the
+// glyphs, see Encoding.DecodeFromStringSep.
+func DecodeFromStringSep(s string, sep string) (dest []byte, err error) {
+	return StdEncoding.DecodeFromStringSep(s, sep)
+}
+
+// EncodeToStringSep encodes a given byte array of data into a base32k string
+// like EncodeToString, but with sep between every two runes, e.g. a space for
+// displaying the glyphs while debugging. DecodeFromStringSep decodes the
+// result. None of the runes of sep may be a glyph or padding symbol of the
+// encoding, otherwise ErrInvalidSeparator is returned.
+func (enc *Encoding) EncodeToStringSep(src []byte, sep string) (dest string, err error) {
+	if !enc.validSeparators(sep) {
+		return "", ErrInvalidSeparator
+	}
+	var destBuf strings.Builder
+	destBuf.Grow(enc.EncodedByteLength(len(src)) + max(enc.EncodedLength(len(src))-1, 0)*len(sep))
+	enc.encodeRunes(src, func(r rune) {
+		if destBuf.Len() > 0 {
+			destBuf.WriteString(sep)
+		}
+		destBuf.WriteRune(r)
+	})
+	return destBuf.String(), nil
+}
+
+// DecodeFromStringSep decodes a base32k string like DecodeFromString, but
+// skips the runes of sep wherever they occur, e.g. the output of
+// EncodeToStringSep or glyphs grouped with spaces by hand. The same
+// restrictions on sep apply as for EncodeToStringSep. Error positions count
+// the skipped runes.
+func (enc *Encoding) DecodeFromStringSep(s string, sep string) (dest []byte, err error) {
+	if !enc.validSeparators(sep) {
+		return nil, ErrInvalidSeparator
+	}
+	// Trailing separators would keep the padding symbol from being the last
+	// rune.
+	s = strings.TrimRight(s, sep)
+	if enc.trimSpace {
+		s = strings.TrimRight(s, asciiSpace)
+	}
+	if err = enc.checkInput(len(s)); err != nil || len(s) == 0 {
+		return nil, err
+	}
+	d := newDecoder(enc)
+	d.separator = sep
+	if err = decodeUTF8(&d, s); err != nil {
+		return []byte{}, err
+	}
+	return d.out, nil
+}
+
+// validSeparators reports whether all runes of sep can separate glyphs of
+// enc, see validSeparator.
+func (enc *Encoding) validSeparators(sep string) bool {
+	if !utf8.ValidString(sep) {
+		return false
+	}
+	for _, r := range sep {
+		if !enc.validSeparator(r) {
+			return false
+		}
+	}
+	return true
+}
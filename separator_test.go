@@ -0,0 +1,79 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestEncodeToStringSep(t *testing.T) {
+	for n, expectedString := range encodeExpectedStrings {
+		encoded, err := EncodeToStringSep(srcData[:n], " · ")
+		if expected := strings.Join(strings.Split(expectedString, ""), " · "); err != nil || encoded != expected {
+			t.Error(fmt.Sprintf("[%d] String '%s' doesn't match expected string '%s' (%v)", n, encoded, expected, err))
+		}
+		if decoded, err := DecodeFromStringSep(encoded, " · "); err != nil || !bytes.Equal(decoded, srcData[:n]) {
+			t.Error(fmt.Sprintf("[%d] Round trip failed: %v (%v)", n, decoded, err))
+		}
+	}
+	data := make([]byte, 300)
+	rand.Read(data)
+	encodings := []*Encoding{StdEncoding, SafeEncoding, BMPEncoding, StdEncoding.WithMarker(),
+		StdEncoding.WithLengthGlyph(), StdEncoding.WithExclusions(testExclusions...)}
+	for _, enc := range encodings {
+		for n := 0; n <= len(data); n += 13 {
+			encoded, err := enc.EncodeToStringSep(data[:n], "\n--\n")
+			if err != nil || strings.ReplaceAll(encoded, "\n--\n", "") != enc.EncodeToString(data[:n]) {
+				t.Error(fmt.Sprintf("[%s/%d] Separated encoding differs: %q (%v)", enc, n, encoded, err))
+			}
+			if decoded, err := enc.DecodeFromStringSep(encoded, "\n--\n"); err != nil || !bytes.Equal(decoded, data[:n]) {
+				t.Error(fmt.Sprintf("[%s/%d] Round trip failed: %v", enc, n, err))
+			}
+		}
+	}
+	// The runes of the separator are skipped anywhere, also at the end.
+	grouped := "  " + encodeExpectedStrings[16][:6] + " " + encodeExpectedStrings[16][6:] + "\t "
+	if decoded, err := DecodeFromStringSep(grouped, " \t"); err != nil || !bytes.Equal(decoded, srcData[:16]) {
+		t.Error(fmt.Sprintf("Grouped glyphs didn't decode: %v (%v)", decoded, err))
+	}
+	// A byte order mark and the marker are skipped after separators, too.
+	marked := StdEncoding.WithMarker()
+	prefixed := " \ufeff " + marked.EncodeToString(srcData[:9])
+	if decoded, err := marked.DecodeFromStringSep(prefixed, " "); err != nil || !bytes.Equal(decoded, srcData[:9]) {
+		t.Error(fmt.Sprintf("Byte order mark, separator and marker didn't decode: %v (%v)", decoded, err))
+	}
+	// Error positions count the separators.
+	encoded, _ := EncodeToStringSep(srcData[:9], " ")
+	corrupted := []rune(encoded)
+	corrupted[4] = '!'
+	var corrupt CorruptInputError
+	if _, err := DecodeFromStringSep(string(corrupted), " "); !errors.As(err, &corrupt) || corrupt.Position != 4 {
+		t.Error(fmt.Sprintf("Expected an error at rune 4, got %v", err))
+	}
+}
+
+func TestEncodeToStringSepInvalid(t *testing.T) {
+	for _, sep := range []string{"a b", " " + string(EncodeRune(0x1234)), " j", "\xff"} {
+		if _, err := EncodeToStringSep(srcData[:4], sep); err != ErrInvalidSeparator {
+			t.Error(fmt.Sprintf("[%q] Expected ErrInvalidSeparator, got %v", sep, err))
+		}
+		if _, err := DecodeFromStringSep(encodeExpectedStrings[4], sep); err != ErrInvalidSeparator {
+			t.Error(fmt.Sprintf("[%q] Expected ErrInvalidSeparator for decoding, got %v", sep, err))
+		}
+	}
+	// The escapes of excluded glyphs and the glyphs of BMPEncoding are part of
+	// the alphabet as well.
+	if _, err := StdEncoding.WithExclusions(testExclusions...).EncodeToStringSep(nil, string(rune(escapeLow))); err != ErrInvalidSeparator {
+		t.Error(fmt.Sprintf("Expected ErrInvalidSeparator for an escape, got %v", err))
+	}
+	if _, err := BMPEncoding.EncodeToStringSep(nil, "あ"); err != ErrInvalidSeparator {
+		t.Error(fmt.Sprintf("Expected ErrInvalidSeparator for a BMPEncoding glyph, got %v", err))
+	}
+}
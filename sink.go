@@ -0,0 +1,46 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import "unicode/utf8"
+
+// A RuneSink receives the runes of an encoding, e.g. a *bytes.Buffer, a
+// *strings.Builder, a *bufio.Writer or a CountingSink.
+type RuneSink interface {
+	WriteRune(r rune) (size int, err error)
+}
+
+// EncodeToSink encodes a given byte array of data with StdEncoding and writes
+// the base32k runes to sink, see Encoding.EncodeToSink.
+func EncodeToSink(sink RuneSink, src []byte) error { return StdEncoding.EncodeToSink(sink, src) }
+
+// EncodeToSink encodes a given byte array of data and writes the base32k runes
+// to sink one by one. It stops writing at the first error returned by sink and
+// returns it.
+func (enc *Encoding) EncodeToSink(sink RuneSink, src []byte) error {
+	var err error
+	enc.encodeRunes(src, func(r rune) {
+		if err == nil {
+			_, err = sink.WriteRune(r)
+		}
+	})
+	return err
+}
+
+// A CountingSink is a RuneSink that only counts the runes written to it and
+// their length in UTF-8, without storing them. This measures an encoding
+// without allocating it.
+type CountingSink struct {
+	Runes int
+	Bytes int
+}
+
+// WriteRune counts r, it never fails.
+func (sink *CountingSink) WriteRune(r rune) (size int, err error) {
+	size = utf8.RuneLen(r)
+	sink.Runes += 1
+	sink.Bytes += size
+	return size, nil
+}
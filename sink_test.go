@@ -0,0 +1,68 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// failingSink accepts limit runes, then fails.
+type failingSink struct {
+	limit int
+}
+
+var errSinkFull = errors.New("Sink full")
+
+func (sink *failingSink) WriteRune(r rune) (int, error) {
+	if sink.limit == 0 {
+		return 0, errSinkFull
+	}
+	sink.limit -= 1
+	return 3, nil
+}
+
+func TestEncodeToSink(t *testing.T) {
+	for n, expectedString := range encodeExpectedStrings {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			var buffer bytes.Buffer
+			var builder strings.Builder
+			var buffered bytes.Buffer
+			writer := bufio.NewWriter(&buffered)
+			for _, sink := range []RuneSink{&buffer, &builder, writer} {
+				if err := EncodeToSink(sink, srcData[:n]); err != nil {
+					t.Error(fmt.Sprintf("[%d] Error while encoding: %s", n, err))
+				}
+			}
+			writer.Flush()
+			for _, encoded := range []string{buffer.String(), builder.String(), buffered.String()} {
+				if encoded != expectedString {
+					t.Error(fmt.Sprintf("[%d] String '%s' doesn't match expected string '%s'", n, encoded, expectedString))
+				}
+			}
+			var counter CountingSink
+			EncodeToSink(&counter, srcData[:n])
+			if counter.Runes != len([]rune(expectedString)) || counter.Bytes != len(expectedString) {
+				t.Error(fmt.Sprintf("[%d] Counted %d runes, %d bytes", n, counter.Runes, counter.Bytes))
+			}
+		})
+	}
+	sink := &failingSink{limit: 3}
+	if err := EncodeToSink(sink, srcData); err != errSinkFull {
+		t.Error("Expected the sink's error, got", err)
+	}
+}
+
+func TestCountingSinkAllocs(t *testing.T) {
+	var counter CountingSink
+	allocs := testing.AllocsPerRun(10, func() { EncodeToSink(&counter, srcData) })
+	if allocs > 0 {
+		t.Error(fmt.Sprintf("Counting allocates %.0f times", allocs))
+	}
+}
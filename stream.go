@@ -0,0 +1,308 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bufio"
+	"io"
+	"unicode/utf8"
+)
+
+// EncodeTo encodes a given byte array of data with StdEncoding and writes the
+// base32k runes to w, see Encoding.EncodeTo.
+func EncodeTo(w io.Writer, src []byte) error { return StdEncoding.EncodeTo(w, src) }
+
+// EncodeTo encodes a given byte array of data and writes the base32k runes to
+// w. The glyphs are written through a bufio.Writer so that unbuffered writers
+// don't receive one write per glyph, and flushed at the end. If w already is a
+// *bufio.Writer it is used directly and not flushed, this is left to the
+// caller.
+func (enc *Encoding) EncodeTo(w io.Writer, src []byte) error {
+	buffered, ok := w.(*bufio.Writer)
+	if !ok {
+		buffered = bufio.NewWriter(w)
+	}
+	if err := enc.EncodeToSink(buffered, src); err != nil || ok {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// NewEncoder returns a new base32k stream encoder which encodes the data
+// written to it with the given encoding and writes the runes to w. Whole
+// blocks of 15 bytes (14 for SafeEncoding) are encoded as soon as they are
+// written, the rest and the padding symbol when the encoder is closed. Close
+// also flushes the buffered runes to w, but doesn't close w itself.
+//
+// An encoding created with WithLengthGlyph can't stream: the length glyph
+// goes first but depends on the length of all the data, so the encoder holds
+// everything written to it in memory and only encodes it on Close.
+func NewEncoder(enc *Encoding, w io.Writer) io.WriteCloser {
+	if enc.lengthGlyph {
+		// The length glyph goes first, so the data is held until Close.
+		return &streamEncoder{enc: enc, w: bufio.NewWriter(w), whole: true}
+	}
+	return &streamEncoder{enc: enc.withoutPrefix(), w: bufio.NewWriter(w), marker: enc.marker}
+}
+
+type streamEncoder struct {
+	enc     *Encoding
+	w       *bufio.Writer
+	marker  bool   // the marker is still to be written
+	whole   bool   // all data is encoded at once, on Close
+	pending []byte // less than a block of data that isn't encoded yet
+	closed  bool
+	err     error
+}
+
+func (se *streamEncoder) Write(p []byte) (n int, err error) {
+	if se.closed {
+		return 0, ErrClosed
+	}
+	if se.err != nil {
+		return 0, se.err
+	}
+	if se.whole {
+		se.pending = append(se.pending, p...)
+		return len(p), nil
+	}
+	// A block of bitsPerRune bytes encodes to exactly 8 glyphs, see
+	// tweetWriter.
+	block := int(se.enc.bitsPerRune)
+	if len(se.pending) > 0 {
+		fill := min(block-len(se.pending), len(p))
+		se.pending = append(se.pending, p[:fill]...)
+		n, p = fill, p[fill:]
+		if len(se.pending) < block {
+			return n, nil
+		}
+		if se.err = se.encode(se.pending); se.err != nil {
+			return n, se.err
+		}
+		se.pending = se.pending[:0]
+	}
+	whole := len(p) / block * block
+	if se.err = se.encode(p[:whole]); se.err != nil {
+		return n, se.err
+	}
+	se.pending = append(se.pending, p[whole:]...)
+	return n + len(p), nil
+}
+
+// Close encodes the rest of the data and flushes the runes to the underlying
+// writer.
+func (se *streamEncoder) Close() error {
+	if se.closed {
+		return ErrClosed
+	}
+	se.closed = true
+	if se.err != nil {
+		return se.err
+	}
+	if err := se.encode(se.pending); err != nil {
+		return err
+	}
+	se.pending = nil
+	return se.w.Flush()
+}
+
+// encode encodes data, preceded by the marker if this is the first data.
+func (se *streamEncoder) encode(data []byte) error {
+	if se.marker && len(data) > 0 {
+		se.marker = false
+		if _, err := se.w.WriteRune(MARKER_SYMBOL); err != nil {
+			return err
+		}
+	}
+	return se.enc.EncodeToSink(se.w, data)
+}
+
+// A DecoderOption changes the behavior of a stream decoder created by
+// NewDecoder.
+type DecoderOption int
+
+const (
+	// StopAtInvalid makes the stream decoder end with io.EOF at the first rune
+	// that is neither a glyph nor a padding symbol, instead of failing. A
+	// padding symbol ends the stream as well. This is useful if the encoded
+	// data is followed by other content, e.g. in a framing protocol.
+	//
+	// The rune that stopped the decoder is put back into the underlying
+	// reader, if that is an io.RuneScanner (e.g. a *bufio.Reader or a
+	// *strings.Reader), so that it is positioned right after the last glyph
+	// or padding symbol. Other readers are wrapped in a bufio.Reader and may
+	// have been read ahead.
+	StopAtInvalid DecoderOption = iota + 1
+)
+
+// NewDecoder returns a new base32k stream decoder which decodes the runes
+// read from r with the given encoding.
+func NewDecoder(enc *Encoding, r io.Reader, options ...DecoderOption) io.Reader {
+	runes, ok := r.(io.RuneScanner)
+	if !ok {
+		runes = bufio.NewReader(r)
+	}
+	sd := &streamDecoder{d: newDecoder(enc), r: runes}
+	for _, option := range options {
+		switch option {
+		case StopAtInvalid:
+			sd.stopAtInvalid = true
+		}
+	}
+	return sd
+}
+
+type streamDecoder struct {
+	d             decoder
+	r             io.RuneScanner
+	index         int // rune index of the next rune read from r
+	read          int // number of bytes of the decoder's output already read
+	stopAtInvalid bool
+	done          bool
+	err           error
+}
+
+func (sd *streamDecoder) Read(p []byte) (n int, err error) {
+	for {
+		// The last decoded byte is held back until it's clear that no
+		// padding symbol removes it.
+		available := len(sd.d.out) - sd.read
+		if !sd.done {
+			available -= 1
+		}
+		if available > 0 {
+			n = copy(p[:min(len(p), available)], sd.d.out[sd.read:])
+			sd.read += n
+			if sd.read == len(sd.d.out)-1 {
+				// Move the held back byte to the front to reuse the buffer.
+				sd.d.out = append(sd.d.out[:0], sd.d.out[sd.read])
+				sd.read = 0
+			}
+			return n, nil
+		}
+		if sd.done {
+			return 0, sd.err
+		}
+		if len(p) == 0 {
+			return 0, nil
+		}
+		sd.step()
+	}
+}
+
+// step reads and decodes the next rune from the underlying reader.
+func (sd *streamDecoder) step() {
+	r, size, err := sd.r.ReadRune()
+	if err != nil {
+		sd.finish(err)
+		return
+	}
+	i := sd.index
+	sd.index += 1
+	if sd.stopAtInvalid {
+		if sd.d.enc.isPadding(r) {
+			sd.finish(sd.d.decodeRune(i, r, true))
+		} else if !sd.d.enc.isGlyph(r) && !sd.d.enc.isEscape(r) && !(sd.d.enc.recoverNFD && isJamo(r)) {
+			sd.r.UnreadRune()
+			sd.finish(nil)
+		} else if err = sd.d.decodeRune(i, r, false); err != nil {
+			sd.finish(err)
+		}
+		return
+	}
+	if r == utf8.RuneError && size == 1 {
+		sequence, truncated := sd.invalidSequence()
+		if surrogate, ok := surrogateHalf(sequence); ok {
+			sd.finish(CorruptInputError{i, surrogate, "Unpaired surrogate half"})
+		} else if truncated {
+			sd.finish(ErrUnexpectedEnd)
+		} else {
+			sd.finish(CorruptInputError{i, r, "Invalid UTF-8 sequence"})
+		}
+		return
+	}
+	last := false
+	if sd.d.enc.lane(r) == 0xfe {
+		// Only the final rune may be a padding symbol, so look ahead.
+		if _, _, err := sd.r.ReadRune(); err == io.EOF {
+			last = true
+		} else if err != nil {
+			sd.finish(err)
+			return
+		} else {
+			sd.r.UnreadRune()
+		}
+	}
+	if err = sd.d.decodeRune(i, r, last); err != nil || last {
+		sd.finish(err)
+	}
+}
+
+// invalidSequence reads the invalid UTF-8 sequence just read again, along with
+// the bytes following it, up to the length of a rune. truncated reports
+// whether the sequence is the start of a rune that is cut off by the end of
+// the input.
+func (sd *streamDecoder) invalidSequence() (sequence []byte, truncated bool) {
+	bytes, ok := sd.r.(io.ByteReader)
+	if !ok || sd.r.UnreadRune() != nil {
+		return nil, false
+	}
+	for len(sequence) < utf8.UTFMax {
+		b, err := bytes.ReadByte()
+		if err != nil {
+			return sequence, err == io.EOF && len(sequence) > 0 && !utf8.FullRune(sequence)
+		}
+		sequence = append(sequence, b)
+	}
+	return sequence, false
+}
+
+// finish ends the stream with err, where a nil error or io.EOF denote a
+// regular end of input.
+func (sd *streamDecoder) finish(err error) {
+	if err == nil || err == io.EOF {
+		err = sd.d.finish()
+	}
+	if err == nil {
+		err = io.EOF
+	}
+	sd.done, sd.err = true, err
+}
+
+// DecodeChan decodes the StdEncoding runes received from in, see
+// Encoding.DecodeChan.
+func DecodeChan(in <-chan rune) (dest []byte, err error) { return StdEncoding.DecodeChan(in) }
+
+// DecodeChan decodes the runes received from in until it is closed, for
+// composing decoding into pipelines of rune-producing stages. The rune
+// received last is the only one that may be a padding symbol. A channel that
+// is closed without sending anything decodes to empty data.
+//
+// On an error, in is drained until it is closed, so that the sending stage
+// doesn't block forever.
+func (enc *Encoding) DecodeChan(in <-chan rune) (dest []byte, err error) {
+	d := newDecoder(enc)
+	// Every rune is held back until the next one shows that it isn't last.
+	held, i := rune(0), -1
+	for r := range in {
+		if i >= 0 {
+			if err = d.decodeRune(i, held, false); err != nil {
+				for range in {
+				}
+				return nil, err
+			}
+		}
+		held, i = r, i+1
+	}
+	if i >= 0 {
+		if err = d.decodeRune(i, held, true); err != nil {
+			return nil, err
+		}
+	}
+	if err = d.finish(); err != nil {
+		return nil, err
+	}
+	return d.out, nil
+}
@@ -0,0 +1,202 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// readAllSmall reads r to the end, requesting only a few bytes at a time.
+func readAllSmall(r io.Reader) (data []byte, err error) {
+	buf := make([]byte, 3)
+	for {
+		n, err := r.Read(buf)
+		data = append(data, buf[:n]...)
+		if err == io.EOF {
+			return data, nil
+		} else if err != nil {
+			return data, err
+		}
+	}
+}
+
+// countingWriter counts how often Write is called on it.
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (n int, err error) {
+	w.writes += 1
+	return w.Buffer.Write(p)
+}
+
+func TestEncodeTo(t *testing.T) {
+	for n, expected := range encodeExpectedBytes {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			var w countingWriter
+			if err := EncodeTo(&w, srcData[:n]); err != nil {
+				t.Error(fmt.Sprintf("[%d] Error while encoding: %s", n, err))
+			}
+			if !bytes.Equal(w.Bytes(), expected) {
+				t.Error(fmt.Sprintf("[%d] Expected %x, got %x", n, expected, w.Bytes()))
+			}
+			if n > 0 && w.writes != 1 {
+				t.Error(fmt.Sprintf("[%d] Expected a single write, got %d", n, w.writes))
+			}
+		})
+	}
+	data := make([]byte, 100000)
+	var w countingWriter
+	EncodeTo(&w, data)
+	if maxWrites := len(w.Bytes())/4096 + 1; w.writes > maxWrites {
+		t.Error(fmt.Sprintf("Expected at most %d writes, got %d", maxWrites, w.writes))
+	}
+	// An existing bufio.Writer is neither wrapped nor flushed.
+	var target countingWriter
+	buffered := bufio.NewWriter(&target)
+	EncodeTo(buffered, srcData)
+	if target.writes != 0 || buffered.Buffered() != len(encodeExpectedBytes[16]) {
+		t.Error(fmt.Sprintf("Expected output to stay buffered, got %d writes", target.writes))
+	}
+}
+
+func TestNewEncoder(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	for _, enc := range []*Encoding{StdEncoding, SafeEncoding, BMPEncoding} {
+		for _, chunk := range []int{1, 2, 14, 15, 16, 100, 1000} {
+			t.Run(fmt.Sprintf("bits_%d_chunk_size_%d", enc.bitsPerRune, chunk), func(t *testing.T) {
+				var w bytes.Buffer
+				encoder := NewEncoder(enc, &w)
+				for start := 0; start < len(data); start += chunk {
+					n, err := encoder.Write(data[start:min(start+chunk, len(data))])
+					if err != nil || n != min(chunk, len(data)-start) {
+						t.Fatal(fmt.Sprintf("[%d] Wrote %d bytes (%v)", chunk, n, err))
+					}
+				}
+				if err := encoder.Close(); err != nil {
+					t.Error(fmt.Sprintf("[%d] Error while closing: %s", chunk, err))
+				}
+				if expected := enc.Encode(data); !bytes.Equal(w.Bytes(), expected) {
+					t.Error(fmt.Sprintf("[%d] Stream encoding differs from Encode", chunk))
+				}
+			})
+		}
+	}
+	encoder := NewEncoder(StdEncoding, io.Discard)
+	encoder.Close()
+	if _, err := encoder.Write(srcData); err != ErrClosed {
+		t.Error("Expected ErrClosed, got", err)
+	}
+	failing := NewEncoder(StdEncoding, errorWriter{})
+	failing.Write(make([]byte, 10000))
+	if err := failing.Close(); err == nil {
+		t.Error("Expected the error of the underlying writer")
+	}
+}
+
+// errorWriter fails every write.
+type errorWriter struct{}
+
+func (errorWriter) Write(p []byte) (int, error) { return 0, errors.New("Write failed") }
+
+func TestNewDecoder(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			for _, r := range []io.Reader{strings.NewReader(encoded), iotest.OneByteReader(strings.NewReader(encoded))} {
+				decoded, err := readAllSmall(NewDecoder(StdEncoding, r))
+				if err != nil {
+					t.Error(fmt.Sprintf("[%d] Error while decoding: %s", n, err))
+				}
+				if !bytes.Equal(decoded, srcData[:n]) {
+					t.Error(fmt.Sprintf("[%d] Expected %x, got %x", n, srcData[:n], decoded))
+				}
+			}
+		})
+	}
+	_, err := io.ReadAll(NewDecoder(StdEncoding, strings.NewReader(encodeExpectedStrings[16]+"?")))
+	var corrupt CorruptInputError
+	if !errors.As(err, &corrupt) || corrupt.Position != 10 {
+		t.Error(fmt.Sprintf("Expected CorruptInputError at position 10, got: %v", err))
+	}
+}
+
+func TestNewDecoderStopAtInvalid(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		for _, rest := range []string{"|next field", "\n", "", "x"} {
+			t.Run(fmt.Sprintf("data_size_%d_%q", n, rest), func(t *testing.T) {
+				r := strings.NewReader(encoded + rest)
+				decoded, err := readAllSmall(NewDecoder(StdEncoding, r, StopAtInvalid))
+				if err != nil {
+					t.Error(fmt.Sprintf("[%d] Error while decoding: %s", n, err))
+				}
+				if !bytes.Equal(decoded, srcData[:n]) {
+					t.Error(fmt.Sprintf("[%d] Expected %x, got %x", n, srcData[:n], decoded))
+				}
+				if remaining, _ := io.ReadAll(r); string(remaining) != rest {
+					t.Error(fmt.Sprintf("[%d] Expected reader to be left at %q, got %q", n, rest, remaining))
+				}
+			})
+		}
+	}
+	// A padding symbol ends a field, so padded fields can follow each other.
+	r := strings.NewReader(encodeExpectedStrings[16] + encodeExpectedStrings[3])
+	for _, n := range []int{16, 3} {
+		decoded, err := readAllSmall(NewDecoder(StdEncoding, r, StopAtInvalid))
+		if err != nil || !bytes.Equal(decoded, srcData[:n]) {
+			t.Error(fmt.Sprintf("[%d] Expected %x, got %x (%v)", n, srcData[:n], decoded, err))
+		}
+	}
+}
+
+// sendRunes sends the runes of s on a new channel from a separate goroutine.
+func sendRunes(s string) <-chan rune {
+	in := make(chan rune)
+	go func() {
+		defer close(in)
+		for _, r := range s {
+			in <- r
+		}
+	}()
+	return in
+}
+
+func TestDecodeChan(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			decoded, err := DecodeChan(sendRunes(encoded))
+			if err != nil {
+				t.Error(fmt.Sprintf("[%d] Error while decoding: %s", n, err))
+			}
+			if !bytes.Equal(decoded, srcData[:n]) {
+				t.Error(fmt.Sprintf("[%d] Expected %x, got %x", n, srcData[:n], decoded))
+			}
+		})
+	}
+	in := make(chan rune)
+	close(in)
+	if decoded, err := DecodeChan(in); err != nil || len(decoded) != 0 {
+		t.Error(fmt.Sprintf("Closed channel decoded to %x, %v", decoded, err))
+	}
+	// The channel is drained after the error, or the sender would block.
+	_, err := DecodeChan(sendRunes("缀x老" + encodeExpectedStrings[16]))
+	var corrupt CorruptInputError
+	if !errors.As(err, &corrupt) || corrupt.Position != 1 {
+		t.Error(fmt.Sprintf("Expected CorruptInputError at position 1, got: %v", err))
+	}
+	if _, err := DecodeChan(sendRunes("缀老")); err != ErrUnexpectedEnd {
+		t.Error(fmt.Sprintf("Expected ErrUnexpectedEnd, got: %v", err))
+	}
+}
@@ -0,0 +1,136 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrClosed is returned when writing to a closed writer.
+var ErrClosed = errors.New("Write to closed writer")
+
+// ErrTweetNumber is returned by DecodeTweets for a tweet that doesn't start
+// with its "seq/total" number.
+var ErrTweetNumber = errors.New("Invalid tweet number")
+
+// ErrIncompleteThread is returned by DecodeTweets if tweets are missing from
+// the thread, appear twice or disagree on the total number of tweets.
+var ErrIncompleteThread = errors.New("Incomplete thread of tweets")
+
+// NewTweetWriter returns a writer that encodes the data written to it with
+// StdEncoding and posts it as a thread of tweets, see
+// Encoding.NewTweetWriter.
+func NewTweetWriter(poster func(seq, total int, text string) error, glyphsPerTweet int) io.WriteCloser {
+	return StdEncoding.NewTweetWriter(poster, glyphsPerTweet)
+}
+
+// NewTweetWriter returns a writer that encodes the data written to it and,
+// when closed, posts the encoding as a thread of tweets of glyphsPerTweet
+// glyphs each, or GLYPHS_PER_TWEET if glyphsPerTweet is 0 or less. poster is
+// called once for each tweet in order, with seq counting from 1 to total. The
+// padding symbol is the very last rune of the last tweet, which may therefore
+// consist of just the padding symbol. Close stops at and returns the first
+// error of poster. A poster that numbers the tweets as "seq/total text"
+// creates a thread that DecodeTweets decodes.
+//
+// The data is encoded while it is being written, but as the total number of
+// tweets is only known at the end, all glyphs are held until Close.
+func (enc *Encoding) NewTweetWriter(poster func(seq, total int, text string) error, glyphsPerTweet int) io.WriteCloser {
+	if glyphsPerTweet <= 0 {
+		glyphsPerTweet = GLYPHS_PER_TWEET
+	}
+	return &tweetWriter{enc: enc.withoutPrefix(), prefixed: enc, poster: poster, glyphsPerTweet: glyphsPerTweet}
+}
+
+type tweetWriter struct {
+	enc            *Encoding
+	prefixed       *Encoding // for the prefix of the glyphs, which depends on written
+	written        int
+	poster         func(seq, total int, text string) error
+	glyphsPerTweet int
+	pending        []byte // less than a block of data that isn't encoded yet
+	glyphs         []rune
+	closed         bool
+}
+
+func (tw *tweetWriter) Write(p []byte) (n int, err error) {
+	if tw.closed {
+		return 0, ErrClosed
+	}
+	tw.pending = append(tw.pending, p...)
+	tw.written += len(p)
+	// A block of bitsPerRune bytes encodes to exactly 8 glyphs, so whole
+	// blocks can be encoded right away.
+	block := int(tw.enc.bitsPerRune)
+	if whole := len(tw.pending) / block * block; whole > 0 {
+		tw.enc.encodeRunes(tw.pending[:whole], func(r rune) { tw.glyphs = append(tw.glyphs, r) })
+		tw.pending = append(tw.pending[:0], tw.pending[whole:]...)
+	}
+	return len(p), nil
+}
+
+// Close encodes the rest of the data and posts the thread.
+func (tw *tweetWriter) Close() error {
+	if tw.closed {
+		return ErrClosed
+	}
+	tw.closed = true
+	tw.enc.encodeRunes(tw.pending, func(r rune) { tw.glyphs = append(tw.glyphs, r) })
+	tw.pending = nil
+	var prefix []rune
+	tw.prefixed.encodePrefix(tw.written, func(r rune) { prefix = append(prefix, r) })
+	tw.glyphs = append(prefix, tw.glyphs...)
+	total := (len(tw.glyphs) + tw.glyphsPerTweet - 1) / tw.glyphsPerTweet
+	for seq := 1; seq <= total; seq += 1 {
+		start := (seq - 1) * tw.glyphsPerTweet
+		end := min(start+tw.glyphsPerTweet, len(tw.glyphs))
+		if err := tw.poster(seq, total, string(tw.glyphs[start:end])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeTweets decodes a thread of StdEncoding tweets, see
+// Encoding.DecodeTweets.
+func DecodeTweets(tweets []string) (dest []byte, err error) { return StdEncoding.DecodeTweets(tweets) }
+
+// DecodeTweets decodes a thread of tweets as posted by NewTweetWriter, in any
+// order. Each tweet starts with its number "seq/total", separated from the
+// glyphs by a space, with seq counting from 1. The glyphs are put back in
+// order and decoded at once, as only the last tweet ends in a padding
+// symbol. It returns ErrTweetNumber for a tweet without a valid number and
+// ErrIncompleteThread unless there are exactly the tweets 1 to total. Error
+// positions count the glyphs of the whole thread.
+func (enc *Encoding) DecodeTweets(tweets []string) (dest []byte, err error) {
+	ordered := make([]string, len(tweets))
+	seen := make([]bool, len(tweets))
+	for _, tweet := range tweets {
+		seq, total, text, err := parseTweet(tweet)
+		if err != nil {
+			return nil, err
+		}
+		if total != len(tweets) || seq > total || seen[seq-1] {
+			return nil, ErrIncompleteThread
+		}
+		ordered[seq-1], seen[seq-1] = text, true
+	}
+	return enc.DecodeFromString(strings.Join(ordered, ""))
+}
+
+// parseTweet splits a tweet into its number "seq/total" and its text.
+func parseTweet(tweet string) (seq, total int, text string, err error) {
+	number, text, found := strings.Cut(tweet, " ")
+	seqField, totalField, slash := strings.Cut(number, "/")
+	seq, seqErr := strconv.Atoi(seqField)
+	total, totalErr := strconv.Atoi(totalField)
+	if !found || !slash || seqErr != nil || totalErr != nil || seq < 1 {
+		return 0, 0, "", ErrTweetNumber
+	}
+	return seq, total, text, nil
+}
@@ -0,0 +1,123 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTweetWriter(t *testing.T) {
+	for _, n := range []int{0, 1, 15, 16, 262, 263, 1000} {
+		for _, glyphsPerTweet := range []int{0, 1, 8, 140} {
+			t.Run(fmt.Sprintf("data_size_%d_glyphs_%d", n, glyphsPerTweet), func(t *testing.T) {
+				data := make([]byte, n)
+				rand.Read(data)
+				limit := glyphsPerTweet
+				if limit == 0 {
+					limit = GLYPHS_PER_TWEET
+				}
+				var tweets []string
+				w := NewTweetWriter(func(seq, total int, text string) error {
+					if seq != len(tweets)+1 {
+						t.Error(fmt.Sprintf("[%d] Tweet %d posted out of order", n, seq))
+					}
+					if expected := (EncodedLength(n) + limit - 1) / limit; total != expected {
+						t.Error(fmt.Sprintf("[%d] Thread of %d tweets, expected %d", n, total, expected))
+					}
+					tweets = append(tweets, text)
+					return nil
+				}, glyphsPerTweet)
+				// Write in uneven pieces.
+				for rest := data; len(rest) > 0; {
+					size := min(rand.Intn(20)+1, len(rest))
+					if written, err := w.Write(rest[:size]); err != nil || written != size {
+						t.Fatal(fmt.Sprintf("[%d] Write failed: %d, %v", n, written, err))
+					}
+					rest = rest[size:]
+				}
+				if err := w.Close(); err != nil {
+					t.Fatal(fmt.Sprintf("[%d] Close failed: %s", n, err))
+				}
+				for i, tweet := range tweets {
+					if length := utf8.RuneCountInString(tweet); length > limit || (i < len(tweets)-1 && length != limit) {
+						t.Error(fmt.Sprintf("[%d] Tweet %d has %d glyphs", n, i+1, length))
+					}
+				}
+				joined := strings.Join(tweets, "")
+				if joined != EncodeToString(data) {
+					t.Error(fmt.Sprintf("[%d] Thread doesn't match the encoding", n))
+				}
+				if decoded, err := DecodeFromString(joined); err != nil || !bytes.Equal(decoded, data) {
+					t.Error(fmt.Sprintf("[%d] Round trip failed: %v", n, err))
+				}
+			})
+		}
+	}
+}
+
+func TestTweetWriterErrors(t *testing.T) {
+	failed := errors.New("rate limited")
+	posted := 0
+	w := NewTweetWriter(func(seq, total int, text string) error {
+		posted += 1
+		if seq == 2 {
+			return failed
+		}
+		return nil
+	}, 4)
+	w.Write(srcData)
+	if err := w.Close(); err != failed {
+		t.Error(fmt.Sprintf("Expected the poster's error, got: %v", err))
+	}
+	if posted != 2 {
+		t.Error(fmt.Sprintf("Posting didn't stop at the error, %d tweets posted", posted))
+	}
+	if _, err := w.Write(srcData); err != ErrClosed {
+		t.Error(fmt.Sprintf("Expected ErrClosed, got: %v", err))
+	}
+}
+
+func TestDecodeTweets(t *testing.T) {
+	data := make([]byte, 1000)
+	rand.Read(data)
+	var tweets []string
+	w := NewTweetWriter(func(seq, total int, text string) error {
+		tweets = append(tweets, fmt.Sprintf("%d/%d %s", seq, total, text))
+		return nil
+	}, 0)
+	w.Write(data)
+	w.Close()
+	rand.Shuffle(len(tweets), func(i, j int) { tweets[i], tweets[j] = tweets[j], tweets[i] })
+	if decoded, err := DecodeTweets(tweets); err != nil || !bytes.Equal(decoded, data) {
+		t.Error(fmt.Sprintf("Shuffled thread of %d tweets didn't decode: %v", len(tweets), err))
+	}
+	// Only the last tweet carries the padding symbol, see NewTweetWriter.
+	for i, tweet := range tweets {
+		if last, _ := utf8.DecodeLastRuneInString(tweet); StdEncoding.isPadding(last) != strings.HasPrefix(tweet, fmt.Sprintf("%d/", len(tweets))) {
+			t.Error(fmt.Sprintf("[%d] Padding symbol in %q", i, tweet))
+		}
+	}
+	invalid := map[string][]string{
+		"missing":   tweets[1:],
+		"duplicate": append([]string{tweets[1]}, tweets[1:]...),
+		"total":     append(append([]string{}, tweets[1:]...), fmt.Sprintf("%d/%d ", len(tweets)+1, len(tweets)+1)),
+	}
+	for name, thread := range invalid {
+		if _, err := DecodeTweets(thread); !errors.Is(err, ErrIncompleteThread) {
+			t.Error(fmt.Sprintf("[%s] Expected ErrIncompleteThread, got %v", name, err))
+		}
+	}
+	for _, tweet := range []string{"", "1/1", "1 " + EncodeToString(data[:10]), "0/1 ", "x/1 ", "1/y "} {
+		if _, err := DecodeTweets([]string{tweet}); !errors.Is(err, ErrTweetNumber) {
+			t.Error(fmt.Sprintf("[%q] Expected ErrTweetNumber, got %v", tweet, err))
+		}
+	}
+}
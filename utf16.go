@@ -0,0 +1,50 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// DecodeFromUTF16 decodes StdEncoding text in UTF-16, see
+// Encoding.DecodeFromUTF16.
+func DecodeFromUTF16(u []uint16) (dest []byte, err error) { return StdEncoding.DecodeFromUTF16(u) }
+
+// DecodeFromUTF16 decodes base32k text in UTF-16, e.g. from a JavaScript string
+// or a Windows API, without converting it to UTF-8 or runes first. The glyphs
+// of StdEncoding and SafeEncoding are single code units, only BMPEncoding has
+// glyphs outside of the BMP, which are made of a surrogate pair. A surrogate
+// half that isn't part of a pair is corrupt input.
+//
+// The Position of a CorruptInputError is the index of the code unit in u,
+// rather than a rune index.
+func (enc *Encoding) DecodeFromUTF16(u []uint16) (dest []byte, err error) {
+	for enc.trimSpace && len(u) > 0 && strings.ContainsRune(asciiSpace, rune(u[len(u)-1])) {
+		u = u[:len(u)-1]
+	}
+	if err = enc.checkInput(len(u)); err != nil || len(u) == 0 {
+		return nil, err
+	}
+	d := newDecoder(enc)
+	d.out = make([]byte, 0, enc.MaxDecodedLen(len(u)))
+	for i := 0; i < len(u); i += 1 {
+		position, r := i, rune(u[i])
+		if utf16.IsSurrogate(r) {
+			if i+1 == len(u) || utf16.DecodeRune(r, rune(u[i+1])) == utf8.RuneError {
+				return []byte{}, CorruptInputError{position, r, "Unpaired surrogate half"}
+			}
+			r, i = utf16.DecodeRune(r, rune(u[i+1])), i+1
+		}
+		if err = d.decodeRune(position, r, i == len(u)-1); err != nil {
+			return []byte{}, err
+		}
+	}
+	if err = d.finish(); err != nil {
+		return []byte{}, err
+	}
+	return d.out, nil
+}
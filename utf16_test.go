@@ -0,0 +1,50 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestDecodeFromUTF16(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		decoded, err := DecodeFromUTF16(utf16.Encode([]rune(encoded)))
+		if err != nil || !bytes.Equal(decoded, srcData[:n]) {
+			t.Error(fmt.Sprintf("[%d] Expected %x, got %x (%v)", n, srcData[:n], decoded, err))
+		}
+	}
+	// The highest values of BMPEncoding are outside of the BMP.
+	data := []byte{0xff, 0xff, 0x00, 0xff, 0xfe}
+	encoded := utf16.Encode([]rune(BMPEncoding.EncodeToString(data)))
+	if len(encoded) == len([]rune(BMPEncoding.EncodeToString(data))) {
+		t.Fatal("Expected surrogate pairs in the BMPEncoding fixture")
+	}
+	if decoded, err := BMPEncoding.DecodeFromUTF16(encoded); err != nil || !bytes.Equal(decoded, data) {
+		t.Error(fmt.Sprintf("Expected %x, got %x (%v)", data, decoded, err))
+	}
+}
+
+func TestDecodeFromUTF16Surrogates(t *testing.T) {
+	glyphs := utf16.Encode([]rune(encodeExpectedStrings[4]))
+	for i, test := range []struct {
+		units    []uint16
+		position int
+	}{
+		{append([]uint16{0xd800}, glyphs...), 0},                        // a lone high half
+		{append([]uint16{glyphs[0], 0xdc00}, glyphs[1:]...), 1},         // a lone low half
+		{append([]uint16{glyphs[0], 0xdc00, 0xd800}, glyphs[1:]...), 1}, // reversed halves
+		{append(glyphs[:3:3], 0xd800), 3},                               // a high half at the end
+	} {
+		_, err := DecodeFromUTF16(test.units)
+		var corrupt CorruptInputError
+		if !errors.As(err, &corrupt) || corrupt.Position != test.position || corrupt.Reason != "Unpaired surrogate half" {
+			t.Error(fmt.Sprintf("[%d] Expected a surrogate error at %d, got: %v", i, test.position, err))
+		}
+	}
+}
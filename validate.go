@@ -0,0 +1,62 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// ValidUTF8 reports whether src is valid UTF-8 at all, independent of whether
+// its runes are base32k glyphs. It is the same as utf8.Valid and meant as the
+// first of two validation stages for untrusted input, the second being
+// ValidEncoding.
+func ValidUTF8(src []byte) bool { return utf8.Valid(src) }
+
+// ValidEncoding checks whether src is a valid StdEncoding encoding, see
+// Encoding.ValidEncoding.
+func ValidEncoding(src []byte) error { return StdEncoding.ValidEncoding(src) }
+
+// ValidEncoding checks whether src could be decoded without error and returns
+// the first problem found otherwise. Invalid UTF-8 and runes outside of the
+// alphabet are reported as a CorruptInputError with the rune index of the
+// problem, so that it can be passed on to whoever sent the input.
+func (enc *Encoding) ValidEncoding(src []byte) error {
+	if enc.trimSpace {
+		src = bytes.TrimRight(src, asciiSpace)
+	}
+	if err := enc.checkInput(len(src)); err != nil {
+		return err
+	}
+	d := newDecoder(enc)
+	return d.decodeUTF8(src)
+}
+
+// ValidateReader checks whether the runes read from r until EOF are a valid
+// StdEncoding encoding, see Encoding.ValidateReader.
+func ValidateReader(r io.Reader) error { return StdEncoding.ValidateReader(r) }
+
+// ValidateReader checks whether the runes read from r until EOF are a valid
+// encoding, like ValidEncoding does for a byte array. The input is streamed
+// through and nothing is decoded into memory, so this works for input of any
+// size. The first problem is returned as a CorruptInputError with its rune
+// index where possible, errors of r are returned as is.
+func (enc *Encoding) ValidateReader(r io.Reader) error {
+	runes, ok := r.(io.RuneScanner)
+	if !ok {
+		runes = bufio.NewReader(r)
+	}
+	sd := streamDecoder{d: newDecoder(enc), r: runes}
+	sd.d.discard = true
+	for !sd.done {
+		sd.step()
+	}
+	if sd.err == io.EOF {
+		return nil
+	}
+	return sd.err
+}
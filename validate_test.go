@@ -0,0 +1,102 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestValidUTF8(t *testing.T) {
+	for n, encoded := range encodeExpectedBytes {
+		if !ValidUTF8(encoded) {
+			t.Error(fmt.Sprintf("[%d] Encoding is not valid UTF-8", n))
+		}
+	}
+	if ValidUTF8([]byte("缀\xe8\x80")) {
+		t.Error("Truncated UTF-8 sequence is valid UTF-8")
+	}
+	if !ValidUTF8([]byte("abc")) {
+		t.Error("ASCII is not valid UTF-8")
+	}
+}
+
+func TestValidEncoding(t *testing.T) {
+	for n, encoded := range encodeExpectedBytes {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			if err := ValidEncoding(encoded); err != nil {
+				t.Error(fmt.Sprintf("[%d] Valid encoding rejected: %s", n, err))
+			}
+		})
+	}
+	invalid := []struct {
+		src      string
+		position int
+	}{
+		{"缀老\xe8\x80j", 2},
+		{"缀x老j", 1},
+		{"缀老\U0001F600j", 2},
+		{"缀j老", 1},
+	}
+	for _, test := range invalid {
+		err := ValidEncoding([]byte(test.src))
+		var corrupt CorruptInputError
+		if !errors.As(err, &corrupt) {
+			t.Error(fmt.Sprintf("%q: Expected CorruptInputError, got: %v", test.src, err))
+		} else if corrupt.Position != test.position {
+			t.Error(fmt.Sprintf("%q: Error at position %d, expected %d", test.src, corrupt.Position, test.position))
+		}
+	}
+	if err := ValidEncoding([]byte("缀老")); err != ErrUnexpectedEnd {
+		t.Error(fmt.Sprintf("Expected ErrUnexpectedEnd for missing padding, got: %v", err))
+	}
+}
+
+func TestValidateReader(t *testing.T) {
+	for n, encoded := range encodeExpectedStrings {
+		t.Run(fmt.Sprintf("data_size_%d", n), func(t *testing.T) {
+			if err := ValidateReader(strings.NewReader(encoded)); err != nil {
+				t.Error(fmt.Sprintf("[%d] Valid encoding rejected: %s", n, err))
+			}
+			// Without io.RuneScanner.
+			if err := ValidateReader(bytes.NewBufferString(encoded)); err != nil {
+				t.Error(fmt.Sprintf("[%d] Valid encoding rejected: %s", n, err))
+			}
+		})
+	}
+	invalid := []struct {
+		src      string
+		position int
+	}{
+		{"缀老\xe8\x80j", 2},
+		{"缀x老j", 1},
+		{"缀j老", 1},
+		{"缀老jj", 3},
+		{"b", 0},
+	}
+	for _, test := range invalid {
+		err := ValidateReader(strings.NewReader(test.src))
+		var corrupt CorruptInputError
+		if !errors.As(err, &corrupt) {
+			t.Error(fmt.Sprintf("%q: Expected CorruptInputError, got: %v", test.src, err))
+		} else if corrupt.Position != test.position {
+			t.Error(fmt.Sprintf("%q: Error at position %d, expected %d", test.src, corrupt.Position, test.position))
+		}
+	}
+	if err := ValidateReader(strings.NewReader("缀老")); err != ErrUnexpectedEnd {
+		t.Error(fmt.Sprintf("Expected ErrUnexpectedEnd for missing padding, got: %v", err))
+	}
+	// A large input, hidden behind a plain io.Reader.
+	data := make([]byte, 1<<20)
+	rand.Read(data)
+	if err := ValidateReader(io.MultiReader(bytes.NewReader(Encode(data)))); err != nil {
+		t.Error(fmt.Sprintf("Valid large encoding rejected: %s", err))
+	}
+}
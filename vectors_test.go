@@ -0,0 +1,72 @@
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+package base32k
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// vectorsJSON holds the test vectors of StdEncoding, which are meant to be
+// shared with implementations in other languages. Unlike the golden files,
+// they are maintained by hand and must never change.
+//
+//go:embed testdata/vectors.json
+var vectorsJSON []byte
+
+type testVector struct {
+	Description string   `json:"description"`
+	Hex         string   `json:"hex"`
+	Encoded     string   `json:"encoded"`
+	CodePoints  []string `json:"codepoints"`
+}
+
+func TestVectors(t *testing.T) {
+	var vectors struct {
+		Vectors []testVector `json:"vectors"`
+	}
+	if err := json.Unmarshal(vectorsJSON, &vectors); err != nil {
+		t.Fatal(fmt.Sprintf("Error reading test vectors: %s", err))
+	}
+	for i, vector := range vectors.Vectors {
+		t.Run(fmt.Sprintf("vector_%d", i), func(t *testing.T) {
+			data, err := hex.DecodeString(vector.Hex)
+			if err != nil {
+				t.Fatal(fmt.Sprintf("[%d] Invalid hex: %s", i, err))
+			}
+			// The code points spell out the encoding.
+			var codePoints strings.Builder
+			for _, codePoint := range vector.CodePoints {
+				r, err := strconv.ParseUint(strings.TrimPrefix(codePoint, "U+"), 16, 32)
+				if err != nil {
+					t.Fatal(fmt.Sprintf("[%d] Invalid code point %q", i, codePoint))
+				}
+				codePoints.WriteRune(rune(r))
+			}
+			if codePoints.String() != vector.Encoded {
+				t.Error(fmt.Sprintf("[%d] Code points don't match the encoding of '%s'", i, vector.Description))
+			}
+			if encoded := EncodeToString(data); encoded != vector.Encoded {
+				t.Error(fmt.Sprintf("[%d] Encoded '%s' to '%s', expected '%s'", i, vector.Description, encoded, vector.Encoded))
+			}
+			if decoded, err := DecodeFromString(vector.Encoded); err != nil || !bytes.Equal(decoded, data) {
+				t.Error(fmt.Sprintf("[%d] Decoded '%s' to %x, expected %x (%v)", i, vector.Description, decoded, data, err))
+			}
+		})
+	}
+	// The vectors formalize the fixtures of the other tests.
+	for _, vector := range vectors.Vectors[:17] {
+		data, _ := hex.DecodeString(vector.Hex)
+		if expected, ok := encodeExpectedStrings[len(data)]; ok && (expected != vector.Encoded || !bytes.Equal(data, srcData[:len(data)])) {
+			t.Error(fmt.Sprintf("Vector '%s' doesn't match the fixture '%s'", vector.Description, expected))
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//go:build js && wasm
+
+/* CC0 - free software.
+To the extent possible under law, all copyright and related or neighboring
+rights to this work are waived. See the LICENSE file for more information. */
+
+// The wasm command registers the base32k functions for JavaScript and keeps
+// them available until the page is closed. Build it with:
+//
+//	GOOS=js GOARCH=wasm go build -o base32k.wasm ./wasm
+//
+// and load it with the wasm_exec.js that comes with Go, from
+// $(go env GOROOT)/lib/wasm (misc/wasm before Go 1.24).
+package main
+
+import "github.com/grandchild/base32k"
+
+func main() {
+	base32k.RegisterJS()
+	select {}
+}